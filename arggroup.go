@@ -0,0 +1,113 @@
+package argparse
+
+import "strings"
+
+// ArgumentGroup lists related flags under their own heading in help. Flags
+// added through a group parse exactly like flags added to the parser.
+type ArgumentGroup struct {
+	parser *Parser
+	title  string
+	args   []*Argument
+}
+
+// NewArgumentGroup creates a help section titled title, shown after the
+// optional arguments in the order groups are created
+func (p *Parser) NewArgumentGroup(title string) *ArgumentGroup {
+	group := &ArgumentGroup{parser: p, title: title}
+	p.argGroups = append(p.argGroups, group)
+	return group
+}
+
+// heading returns the section title, ending in a colon like the built-in ones
+func (g *ArgumentGroup) heading() string {
+	if strings.HasSuffix(g.title, ":") {
+		return g.title
+	}
+	return g.title + ":"
+}
+
+// add records arg as a member of the group
+func (g *ArgumentGroup) add(arg *Argument) *Argument {
+	g.args = append(g.args, arg)
+	return arg
+}
+
+// Flag adds a flag to the group
+func (g *ArgumentGroup) Flag(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Flag(shortName, longName, options))
+}
+
+// String adds a string argument to the group
+func (g *ArgumentGroup) String(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.String(shortName, longName, options))
+}
+
+// Int adds an integer argument to the group
+func (g *ArgumentGroup) Int(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Int(shortName, longName, options))
+}
+
+// Int64 adds a 64-bit integer argument to the group
+func (g *ArgumentGroup) Int64(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Int64(shortName, longName, options))
+}
+
+// Uint adds an unsigned integer argument to the group
+func (g *ArgumentGroup) Uint(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Uint(shortName, longName, options))
+}
+
+// Float adds a float argument to the group
+func (g *ArgumentGroup) Float(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Float(shortName, longName, options))
+}
+
+// Float32 adds a 32-bit float argument to the group
+func (g *ArgumentGroup) Float32(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Float32(shortName, longName, options))
+}
+
+// Bool adds a boolean argument to the group
+func (g *ArgumentGroup) Bool(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Bool(shortName, longName, options))
+}
+
+// List adds a list argument to the group
+func (g *ArgumentGroup) List(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.List(shortName, longName, options))
+}
+
+// Counter adds a counter argument to the group
+func (g *ArgumentGroup) Counter(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Counter(shortName, longName, options))
+}
+
+// DateTime adds a datetime argument to the group
+func (g *ArgumentGroup) DateTime(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.DateTime(shortName, longName, options))
+}
+
+// FeatureSet adds a feature set argument to the group
+func (g *ArgumentGroup) FeatureSet(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.FeatureSet(shortName, longName, options))
+}
+
+// Duration adds a duration argument to the group
+func (g *ArgumentGroup) Duration(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Duration(shortName, longName, options))
+}
+
+// Glob adds a glob pattern argument to the group
+func (g *ArgumentGroup) Glob(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.Glob(shortName, longName, options))
+}
+
+// TimeOfDay adds a time-of-day argument to the group
+func (g *ArgumentGroup) TimeOfDay(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.TimeOfDay(shortName, longName, options))
+}
+
+// JSON adds a JSON argument to the group
+func (g *ArgumentGroup) JSON(shortName, longName string, options *Argument) *Argument {
+	return g.add(g.parser.JSON(shortName, longName, options))
+}
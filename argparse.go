@@ -6,12 +6,19 @@
 package argparse
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // Version information
@@ -21,6 +28,14 @@ const (
 	GitHub  = "bunnyhawper"
 )
 
+// Sentinel errors returned by Parse when the parser does not exit on help/version
+var (
+	// ErrHelpRequested is returned when the help flag is seen
+	ErrHelpRequested = errors.New("help requested")
+	// ErrVersionRequested is returned when the version flag is seen
+	ErrVersionRequested = errors.New("version requested")
+)
+
 // ArgumentType defines the type of an argument
 type ArgumentType int
 
@@ -39,6 +54,22 @@ const (
 	Counter
 	// DateTime argument type
 	DateTime
+	// FeatureSet argument type (set of names from commas and repeats)
+	FeatureSet
+	// JSON argument type (any JSON value, decoded with encoding/json)
+	JSON
+	// Duration argument type (time.ParseDuration syntax such as 1m30s)
+	Duration
+	// Glob argument type (a filepath.Glob pattern, stored as the matching paths)
+	Glob
+	// Uint argument type (non-negative integer stored as uint64)
+	Uint
+	// Int64 argument type (integer stored as int64)
+	Int64
+	// Float32 argument type (single-precision float stored as float32)
+	Float32
+	// TimeOfDay argument type (HH:MM or HH:MM:SS, stored as the time.Duration since midnight)
+	TimeOfDay
 )
 
 // Argument represents a command-line argument
@@ -50,10 +81,38 @@ type Argument struct {
 	ArgType      ArgumentType
 	DefaultVal   interface{}
 	ValidChoices []string
+	IsRemainder  bool
+	DefaultFunc  func(values map[string]interface{}) interface{}
+	DefaultDeps  []string
+	IsPersistent bool
+	IsSensitive  bool
+	IsGreedy     bool
+	IsTerminator bool
+	Metavar      string
+	IsVariadic   bool
+	FixedCount   int
+	MinValue     *float64
+	MaxValue     *float64
+	IsAppend     bool
+	ReplacedBy   string
+	EnvVar       string
+	ChoicesFunc  func() []string
+	Units        map[string]float64
+	BaseUnit     string
+	Validators   []func(value interface{}) error
+	CustomType   string
+	OnlyCommands []string
+	MustMatch    bool
+	Pipeline     []func(value string) (string, error)
+	Layouts      []string
+	Separator    string
+	EmptyIsUnset bool
 	value        interface{}
 	isSet        bool
+	fromEnv      bool
 	isPositional bool
 	parent       *Parser
+	choiceRange  []int
 }
 
 // Parser represents the argument parser
@@ -65,8 +124,52 @@ type Parser struct {
 	args        []*Argument
 	positional  []*Argument
 	subparsers  map[string]*Parser
+	commands    []string
 	parent      *Parser
 	subparser   string
+	noExit      bool
+	helpOnEmpty bool
+	raw         map[string][]string
+	messages    *Messages
+	result      map[string]interface{}
+	parsed      bool
+	compactHelp bool
+	helpWidth   int
+	widthFunc   func() int
+	exitCodes   map[ErrorKind]int
+	exitFunc    func(code int)
+	validators  []func(values map[string]interface{}) error
+	defErr      error
+	handler     Handler
+	requireEq   bool
+	maxArgs     int
+	errVerbose  ErrorVerbosity
+	maxChoices  int
+	groups      []*Group
+	debugOut    io.Writer
+	choicesFlag *Argument
+	choicesFor  *Argument
+	requestedBy *Parser
+	out         io.Writer
+	errOut      io.Writer
+	tolerant    bool
+	preParse    []func(args []string) ([]string, error)
+	defaultCmd  string
+	helpExit    int
+	bindings    []reflect.Value
+	noAbbrev    bool
+	gccErrors   bool
+	chaining    bool
+	chain       []string
+	noDefaults  bool
+	emptyUnset  bool
+	preRun      Hook
+	postRun     Hook
+	preRunAll   Hook
+	postRunAll  Hook
+	argGroups   []*ArgumentGroup
+	requires    []requirement
+	seeAlso     bool
 }
 
 // Command represents a subcommand in the parser
@@ -86,6 +189,12 @@ func NewParser(name string, description string) *Parser {
 	}
 }
 
+// SetDescription sets the description shown in help and in the parent's command list
+func (p *Parser) SetDescription(description string) *Parser {
+	p.description = description
+	return p
+}
+
 // SetEpilog sets the epilog text for the parser
 func (p *Parser) SetEpilog(epilog string) *Parser {
 	p.epilog = epilog
@@ -98,10 +207,107 @@ func (p *Parser) SetVersion(version string) *Parser {
 	return p
 }
 
+// SetExitOnHelp controls whether Run and RunContext print help or version and
+// exit when it is requested. When disabled, they return ErrHelpRequested or
+// ErrVersionRequested instead. Parse never exits; ParseOrExit always does.
+func (p *Parser) SetExitOnHelp(exit bool) *Parser {
+	p.noExit = !exit
+	return p
+}
+
+// HelpOnEmpty makes Parse report a help request when no arguments are given
+// and the parser has required arguments or subcommands
+func (p *Parser) HelpOnEmpty(enable bool) *Parser {
+	p.helpOnEmpty = enable
+	return p
+}
+
+// RequireEquals makes long options take their value only as --name=value;
+// a value in the next token is rejected. Short options are unaffected.
+func (p *Parser) RequireEquals(enable bool) *Parser {
+	p.requireEq = enable
+	return p
+}
+
+// SetMaxArgs makes Parse fail when given more than n arguments, guarding
+// against pathological input. Zero means no limit.
+func (p *Parser) SetMaxArgs(n int) *Parser {
+	p.maxArgs = n
+	return p
+}
+
+// needsArgs reports whether the parser cannot run without any arguments
+func (p *Parser) needsArgs() bool {
+	if len(p.subparsers) > 0 {
+		return true
+	}
+	for _, arg := range p.args {
+		if arg.IsRequired {
+			return true
+		}
+	}
+	for _, arg := range p.positional {
+		if arg.IsRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// options returns the flags accepted by the parser, including persistent
+// flags inherited from parent parsers
+func (p *Parser) options() []*Argument {
+	options := append([]*Argument{}, p.args...)
+	for parent := p.parent; parent != nil; parent = parent.parent {
+		for _, arg := range parent.args {
+			if arg.IsPersistent {
+				options = append(options, arg)
+			}
+		}
+	}
+	return options
+}
+
+// AddVerbosityFlags adds -v/--verbose and -q/--quiet counters; read the net
+// level with Verbosity
+func (p *Parser) AddVerbosityFlags() *Parser {
+	p.Counter("v", "verbose", &Argument{Description: p.msgs().VerboseDescription})
+	p.Counter("q", "quiet", &Argument{Description: p.msgs().QuietDescription})
+	return p
+}
+
+// Verbosity returns the number of -v flags minus the number of -q flags
+func (p *Parser) Verbosity() int {
+	return p.GetInt("verbose") - p.GetInt("quiet")
+}
+
+// AddPreParseHook registers a function that rewrites the arguments before
+// they are parsed, for example to expand aliases. Hooks run in the order they
+// were added, each receiving the previous hook's result.
+func (p *Parser) AddPreParseHook(hook func(args []string) ([]string, error)) *Parser {
+	p.preParse = append(p.preParse, hook)
+	return p
+}
+
+// AddValidator registers a check run after parsing with every resolved value,
+// flags and positionals alike, keyed by name
+func (p *Parser) AddValidator(validate func(values map[string]interface{}) error) *Parser {
+	p.validators = append(p.validators, validate)
+	return p
+}
+
+// root returns the top-level parser
+func (p *Parser) root() *Parser {
+	for p.parent != nil {
+		p = p.parent
+	}
+	return p
+}
+
 // AddHelp adds a help argument to the parser
 func (p *Parser) AddHelp() *Argument {
 	help := p.Flag("h", "help", &Argument{
-		Description: "Show this help message and exit",
+		Description: p.msgs().HelpDescription,
 		ArgType:     Bool,
 		DefaultVal:  false,
 	})
@@ -111,13 +317,43 @@ func (p *Parser) AddHelp() *Argument {
 // AddVersion adds a version argument to the parser
 func (p *Parser) AddVersion() *Argument {
 	version := p.Flag("V", "version", &Argument{
-		Description: "Show program's version and exit",
+		Description: p.msgs().VersionDescription,
 		ArgType:     Bool,
 		DefaultVal:  false,
 	})
 	return version
 }
 
+// SetDefaultCommand sets the subcommand run when the first token is not a
+// subcommand name, as if its name had been typed first
+func (p *Parser) SetDefaultCommand(name string) *Parser {
+	p.defaultCmd = name
+	return p
+}
+
+// isCommandOrOwnFlag reports whether token names a subcommand or one of the
+// parser's own flags, which keep their meaning with a default command
+func (p *Parser) isCommandOrOwnFlag(token string) bool {
+	if _, ok := p.subparsers[token]; ok {
+		return true
+	}
+	name := ""
+	switch {
+	case strings.HasPrefix(token, "--"):
+		name, _, _ = strings.Cut(token[2:], "=")
+	case strings.HasPrefix(token, "-") && len(token) > 1:
+		name = token[1:2]
+	default:
+		return false
+	}
+	for _, arg := range p.args {
+		if arg.Name == name || arg.ShortName == name {
+			return true
+		}
+	}
+	return false
+}
+
 // NewCommand creates a new subcommand
 func (p *Parser) NewCommand(name string, description string) *Command {
 	subparser := &Parser{
@@ -130,6 +366,9 @@ func (p *Parser) NewCommand(name string, description string) *Command {
 		parent:      p,
 	}
 
+	if _, ok := p.subparsers[name]; !ok {
+		p.commands = append(p.commands, name)
+	}
 	p.subparsers[name] = subparser
 
 	return &Command{
@@ -152,6 +391,19 @@ func (p *Parser) Flag(shortName, longName string, options *Argument) *Argument {
 	return options
 }
 
+// Add adds a flag from a "short|long" spec such as "v|verbose", or a
+// long-only spec such as "verbose". The type comes from options.ArgType.
+func (p *Parser) Add(spec string, options *Argument) *Argument {
+	shortName, longName := "", spec
+	if parts := strings.SplitN(spec, "|", 2); len(parts) == 2 {
+		shortName, longName = parts[0], parts[1]
+		if len([]rune(shortName)) != 1 {
+			p.setDefErr(p.errorf(KindInvalidDefinition, spec, p.msgs().InvalidSpec, spec))
+		}
+	}
+	return p.Flag(shortName, longName, options)
+}
+
 // String adds a string argument
 func (p *Parser) String(shortName, longName string, options *Argument) *Argument {
 	if options == nil {
@@ -172,6 +424,27 @@ func (p *Parser) Int(shortName, longName string, options *Argument) *Argument {
 	return p.Flag(shortName, longName, options)
 }
 
+// Int64 adds a 64-bit integer argument; DefaultVal takes an int64
+func (p *Parser) Int64(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = Int64
+
+	return p.Flag(shortName, longName, options)
+}
+
+// Uint adds an unsigned integer argument that rejects negative values;
+// DefaultVal takes a uint64
+func (p *Parser) Uint(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = Uint
+
+	return p.Flag(shortName, longName, options)
+}
+
 // Float adds a float argument
 func (p *Parser) Float(shortName, longName string, options *Argument) *Argument {
 	if options == nil {
@@ -182,6 +455,17 @@ func (p *Parser) Float(shortName, longName string, options *Argument) *Argument
 	return p.Flag(shortName, longName, options)
 }
 
+// Float32 adds a single-precision float argument; values that overflow a
+// float32 are rejected, and DefaultVal takes a float32
+func (p *Parser) Float32(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = Float32
+
+	return p.Flag(shortName, longName, options)
+}
+
 // Bool adds a boolean argument
 func (p *Parser) Bool(shortName, longName string, options *Argument) *Argument {
 	if options == nil {
@@ -229,6 +513,64 @@ func (p *Parser) DateTime(shortName, longName string, options *Argument) *Argume
 	return p.Flag(shortName, longName, options)
 }
 
+// FeatureSet adds a feature set argument. Names accumulate across commas and
+// repeated flags, and are checked against ValidChoices when set.
+func (p *Parser) FeatureSet(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = FeatureSet
+
+	return p.Flag(shortName, longName, options)
+}
+
+// Duration adds a duration argument such as --timeout 30s; DefaultVal takes
+// a time.Duration
+func (p *Parser) Duration(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = Duration
+
+	return p.Flag(shortName, longName, options)
+}
+
+// Glob adds an argument whose value is a file pattern such as '*.log',
+// expanded relative to the working directory into the matching paths
+func (p *Parser) Glob(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = Glob
+	if options.DefaultVal == nil {
+		options.DefaultVal = make([]string, 0)
+	}
+
+	return p.Flag(shortName, longName, options)
+}
+
+// TimeOfDay adds an argument taking a time of day such as 14:30 or
+// 14:30:15, stored as the time.Duration since midnight
+func (p *Parser) TimeOfDay(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = TimeOfDay
+
+	return p.Flag(shortName, longName, options)
+}
+
+// JSON adds an argument whose value is decoded as JSON, giving a
+// map[string]interface{}, []interface{}, string, float64, bool or nil
+func (p *Parser) JSON(shortName, longName string, options *Argument) *Argument {
+	if options == nil {
+		options = &Argument{}
+	}
+	options.ArgType = JSON
+
+	return p.Flag(shortName, longName, options)
+}
+
 // Positional adds a positional argument
 func (p *Parser) Positional(name string, options *Argument) *Argument {
 	if options == nil {
@@ -267,322 +609,1322 @@ func (a *Argument) Choices(choices []string) *Argument {
 	return a
 }
 
-// Parse parses the command line arguments
-func (p *Parser) Parse(args []string) (map[string]interface{}, error) {
-	if args == nil {
-		args = os.Args[1:]
-	}
+// DefaultFrom computes the default from other arguments' values once they are
+// resolved. Returning nil keeps the static default.
+func (a *Argument) DefaultFrom(fn func(values map[string]interface{}) interface{}, deps ...string) *Argument {
+	a.DefaultFunc = fn
+	a.DefaultDeps = deps
+	return a
+}
 
-	// Initialize result map
-	result := make(map[string]interface{})
+// Persistent makes a flag also accepted by all subcommands of its parser
+func (a *Argument) Persistent() *Argument {
+	a.IsPersistent = true
+	return a
+}
 
-	// Add default values
-	for _, arg := range p.args {
-		if arg.DefaultVal != nil {
-			result[arg.Name] = arg.DefaultVal
+// OnlyFor makes a flag persistent but restricted to the given subcommands
+func (a *Argument) OnlyFor(commands ...string) *Argument {
+	a.IsPersistent = true
+	a.OnlyCommands = commands
+	return a
+}
+
+// allowedIn reports whether a persistent flag may be used with the given command
+func (a *Argument) allowedIn(command string) bool {
+	if len(a.OnlyCommands) == 0 {
+		return true
+	}
+	for _, name := range a.OnlyCommands {
+		if name == command {
+			return true
 		}
 	}
+	return false
+}
 
-	for _, arg := range p.positional {
-		if arg.DefaultVal != nil {
-			result[arg.Name] = arg.DefaultVal
+// checkOnlyFor reports a flag given before the command name that OnlyFor
+// does not allow with command
+func (p *Parser) checkOnlyFor(command string) error {
+	for _, arg := range p.args {
+		if arg.isSet && !arg.allowedIn(command) {
+			display := "--" + arg.Name
+			if arg.Name == "" {
+				display = "-" + arg.ShortName
+			}
+			return p.errorf(KindNotAllowed, display, p.msgs().NotAllowedWithCommand, display, command)
 		}
 	}
+	return nil
+}
 
-	// Process arguments
-	positionalIndex := 0
-	hasVersionFlag := false
-	hasHelpFlag := false
+// Sensitive masks the argument's value when the command line is reconstructed
+func (a *Argument) Sensitive() *Argument {
+	a.IsSensitive = true
+	return a
+}
 
-	for i := 0; i < len(args); i++ {
-		arg := args[i]
+// Greedy makes a string argument take every following token up to the next
+// flag or "--", joined with spaces
+func (a *Argument) Greedy() *Argument {
+	a.IsGreedy = true
+	return a
+}
 
-		// Check for subcommand
-		if len(p.subparsers) > 0 && i == 0 {
-			if subparser, ok := p.subparsers[arg]; ok {
-				p.subparser = arg
-				subResult, err := subparser.Parse(args[1:])
-				if err != nil {
-					return nil, err
-				}
+// Append makes each occurrence of the flag add to its values instead of
+// replacing them: --file a --file b,c gives a List ["a", "b", "c"], and an Int
+// collects an []int. The default applies only when the flag never appears.
+func (a *Argument) Append() *Argument {
+	a.IsAppend = true
+	return a
+}
 
-				result["subcommand"] = arg
-				for k, v := range subResult {
-					result[k] = v
-				}
-				return result, nil
-			}
-		}
+// Env sets an environment variable the argument falls back to when it is not
+// given on the command line. The command line wins over the variable, and the
+// variable over the default.
+func (a *Argument) Env(name string) *Argument {
+	a.EnvVar = name
+	return a
+}
 
-		// Process flags
-		if strings.HasPrefix(arg, "-") {
-			var name string
-			var value string
-			hasValue := false
+// Min sets the smallest value an Int or Float argument accepts, inclusive
+func (a *Argument) Min(n float64) *Argument {
+	a.MinValue = &n
+	return a
+}
 
-			if strings.HasPrefix(arg, "--") {
-				// Long option
-				parts := strings.SplitN(arg[2:], "=", 2)
-				name = parts[0]
-				if len(parts) > 1 {
-					value = parts[1]
-					hasValue = true
-				}
+// Max sets the largest value an Int or Float argument accepts, inclusive
+func (a *Argument) Max(n float64) *Argument {
+	a.MaxValue = &n
+	return a
+}
 
-				// Check for help and version flags
-				if name == "help" {
-					hasHelpFlag = true
-					result["help"] = true
-				} else if name == "version" {
-					hasVersionFlag = true
-					result["version"] = true
-				}
+// Range sets the inclusive bounds of an Int or Float argument
+func (a *Argument) Range(lo, hi float64) *Argument {
+	return a.Min(lo).Max(hi)
+}
 
-				// Find matching argument
-				found := false
-				for _, option := range p.args {
-					if option.Name == name {
-						found = true
-
-						switch option.ArgType {
-						case Bool:
-							result[option.Name] = true
-							option.isSet = true
-
-						case Counter:
-							count, _ := result[option.Name].(int)
-							result[option.Name] = count + 1
-							option.isSet = true
-
-						default:
-							if hasValue {
-								parsedValue, err := parseValue(option.ArgType, value)
-								if err != nil {
-									return nil, fmt.Errorf("invalid value for --%s: %v", name, err)
-								}
-								result[option.Name] = parsedValue
-								option.isSet = true
-							} else {
-								if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-									i++
-									parsedValue, err := parseValue(option.ArgType, args[i])
-									if err != nil {
-										return nil, fmt.Errorf("invalid value for --%s: %v", name, err)
-									}
-									result[option.Name] = parsedValue
-									option.isSet = true
-								} else {
-									return nil, fmt.Errorf("argument --%s requires a value", name)
-								}
-							}
-						}
-						break
-					}
-				}
+// Unit makes the argument take a number with a unit suffix, such as 5km,
+// converted to base by the suffix's multiplier and stored as a float64.
+// A number without a suffix is taken to be in base.
+func (a *Argument) Unit(base string, multipliers map[string]float64) *Argument {
+	a.BaseUnit = base
+	a.Units = multipliers
+	return a
+}
 
-				if !found {
-					return nil, fmt.Errorf("unknown argument: --%s", name)
-				}
+// DeprecatedInFavorOf marks the flag as deprecated: using it prints a warning
+// and also sets the named replacement, so code only needs to read the new name
+func (a *Argument) DeprecatedInFavorOf(name string) *Argument {
+	a.ReplacedBy = name
+	return a
+}
 
-			} else {
-				// Short option
-				shortName := arg[1:]
-
-				// Check for help and version flags
-				if shortName == "h" {
-					hasHelpFlag = true
-					result["help"] = true
-				} else if shortName == "V" {
-					hasVersionFlag = true
-					result["version"] = true
-				}
+// Terminates makes the flag end parsing like --version does: when it is
+// given, required arguments and validators are not checked
+func (a *Argument) Terminates() *Argument {
+	a.IsTerminator = true
+	return a
+}
 
-				// Handle multiple short options (e.g., -abc)
-				for j, shortOpt := range shortName {
-					found := false
-					for _, option := range p.args {
-						if option.ShortName == string(shortOpt) {
-							found = true
-
-							switch option.ArgType {
-							case Bool:
-								result[option.Name] = true
-								option.isSet = true
-
-							case Counter:
-								count, _ := result[option.Name].(int)
-								result[option.Name] = count + 1
-								option.isSet = true
-
-							default:
-								if j < len(shortName)-1 {
-									// If not the last character, use the rest as value
-									value = shortName[j+1:]
-									parsedValue, err := parseValue(option.ArgType, value)
-									if err != nil {
-										return nil, fmt.Errorf("invalid value for -%c: %v", shortOpt, err)
-									}
-									result[option.Name] = parsedValue
-									option.isSet = true
-									j = len(shortName) // Stop processing
-								} else {
-									if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-										i++
-										parsedValue, err := parseValue(option.ArgType, args[i])
-										if err != nil {
-											return nil, fmt.Errorf("invalid value for -%c: %v", shortOpt, err)
-										}
-										result[option.Name] = parsedValue
-										option.isSet = true
-									} else {
-										return nil, fmt.Errorf("argument -%c requires a value", shortOpt)
-									}
-								}
-							}
-							break
-						}
-					}
-
-					if !found {
-						return nil, fmt.Errorf("unknown argument: -%c", shortOpt)
-					}
-
-					// If we used the rest of the string as a value, stop processing
-					if j+1 < len(shortName) {
-						break
-					}
-				}
-			}
-		} else {
-			// Positional argument
-			if positionalIndex < len(p.positional) {
-				pos := p.positional[positionalIndex]
-				parsedValue, err := parseValue(pos.ArgType, arg)
-				if err != nil {
-					return nil, fmt.Errorf("invalid value for %s: %v", pos.Name, err)
-				}
-				result[pos.Name] = parsedValue
-				pos.isSet = true
-				positionalIndex++
-			} else {
-				return nil, fmt.Errorf("unrecognized positional argument: %s", arg)
-			}
-		}
-	}
+// Sep sets the separator between the values of a List argument, instead
+// of a comma
+func (a *Argument) Sep(separator string) *Argument {
+	a.Separator = separator
+	return a
+}
 
-	// Special case: --help or -h
-	if hasHelpFlag {
-		p.PrintHelp()
-		os.Exit(0)
+// separator returns the List separator in effect
+func (a *Argument) separator() string {
+	if a.Separator != "" {
+		return a.Separator
 	}
+	return ","
+}
 
-	// Special case: --version or -V
-	if hasVersionFlag {
-		fmt.Printf("%s %s\n", p.name, p.version)
-		os.Exit(0)
-	}
+// TreatEmptyAsUnset makes an empty value, as in --name "", fail the
+// required check like an argument that was not given
+func (a *Argument) TreatEmptyAsUnset() *Argument {
+	a.EmptyIsUnset = true
+	return a
+}
 
-	// Check required arguments (only if help/version not specified)
-	for _, arg := range p.args {
-		if arg.IsRequired && !arg.isSet {
-			if arg.isPositional {
-				return nil, fmt.Errorf("required argument missing: %s", arg.Name)
-			} else {
-				if arg.ShortName != "" {
-					return nil, fmt.Errorf("required argument missing: --%s/-%s", arg.Name, arg.ShortName)
-				} else {
-					return nil, fmt.Errorf("required argument missing: --%s", arg.Name)
-				}
-			}
-		}
-	}
+// Variadic makes a positional collect every remaining positional token into a
+// slice of its type, such as []string or []int
+func (a *Argument) Variadic() *Argument {
+	a.IsVariadic = true
+	return a
+}
 
-	for _, arg := range p.positional {
-		if arg.IsRequired && !arg.isSet {
-			return nil, fmt.Errorf("required positional argument missing: %s", arg.Name)
+// Count makes a positional take exactly n tokens, collected into a slice of
+// its type like Variadic. Fewer than n is an error.
+func (a *Argument) Count(n int) *Argument {
+	a.FixedCount = n
+	a.IsRequired = true
+	return a
+}
+
+// collects reports whether a positional gathers several tokens into a slice
+func (a *Argument) collects() bool {
+	return a.IsVariadic || a.FixedCount > 0
+}
+
+// Nargs sets how many tokens a positional takes, like Python's argparse:
+// "?" for an optional single value, "*" for any number, "+" for at least
+// one, or a number for exactly that many, as with Count. All but "?" collect
+// the values like Variadic; read strings with GetList.
+func (a *Argument) Nargs(nargs string) *Argument {
+	switch nargs {
+	case "?":
+		a.IsRequired = false
+	case "*":
+		a.IsVariadic = true
+		a.IsRequired = false
+	case "+":
+		a.IsVariadic = true
+		a.IsRequired = true
+	default:
+		if n, err := strconv.Atoi(nargs); err == nil && n > 0 {
+			return a.Count(n)
+		}
+		if a.parent != nil {
+			a.parent.setDefErr(a.parent.errorf(KindInvalidDefinition, a.Name, a.parent.msgs().InvalidNargs, nargs, a.Name))
 		}
 	}
+	return a
+}
 
-	return result, nil
+// Validate adds a check run on the parsed value, after any earlier ones; for
+// variadic positionals it runs on each element and all failures are reported
+// together
+func (a *Argument) Validate(validate func(value interface{}) error) *Argument {
+	a.Validators = append(a.Validators, validate)
+	return a
 }
 
-// ParseOrExit parses command line arguments or exits on error
-func (p *Parser) ParseOrExit() map[string]interface{} {
-	result, err := p.Parse(nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
-		p.PrintHelp()
-		os.Exit(1)
+// validate runs the validators in order and returns the first failure
+func (a *Argument) validate(value interface{}) error {
+	for _, validate := range a.Validators {
+		if err := validate(value); err != nil {
+			return err
+		}
 	}
-	return result
+	return nil
 }
 
-// PrintHelp prints the help message
-func (p *Parser) PrintHelp() {
-	fmt.Printf("Usage: %s", p.name)
+// RequireMatch makes a Glob argument fail when its pattern matches no files
+func (a *Argument) RequireMatch() *Argument {
+	a.MustMatch = true
+	return a
+}
 
-	if len(p.args) > 0 {
-		fmt.Printf(" [options]")
-	}
+// Remainder marks a positional as consuming all remaining tokens verbatim.
+// It must be the last positional of its parser.
+func (a *Argument) Remainder() *Argument {
+	a.IsRemainder = true
+	return a
+}
 
-	for _, pos := range p.positional {
-		if pos.IsRequired {
-			fmt.Printf(" %s", pos.Name)
-		} else {
-			fmt.Printf(" [%s]", pos.Name)
-		}
+// Parse parses the command line arguments and stores the result for Get and
+// the other getters
+func (p *Parser) Parse(args []string) (map[string]interface{}, error) {
+	if args == nil {
+		args = os.Args[1:]
 	}
-
-	if len(p.subparsers) > 0 {
-		fmt.Printf(" {")
-		first := true
-		for name := range p.subparsers {
-			if !first {
-				fmt.Printf(",")
-			}
-			fmt.Printf("%s", name)
-			first = false
+	p.parsed = true
+	p.result = nil
+	p.requestedBy = nil
+	p.resetCommands()
+
+	for _, hook := range p.preParse {
+		rewritten, err := hook(append([]string{}, args...))
+		if err != nil {
+			return nil, err
 		}
-		fmt.Printf("}")
+		args = rewritten
 	}
 
-	fmt.Printf("\n\n%s\n\n", p.description)
-
-	if len(p.positional) > 0 {
-		fmt.Printf("Positional arguments:\n")
-		for _, pos := range p.positional {
-			fmt.Printf("  %-20s %s\n", pos.Name, pos.Description)
-		}
-		fmt.Printf("\n")
+	if max := p.root().maxArgs; max > 0 && len(args) > max {
+		return nil, p.errorf(KindTooManyArguments, "", p.msgs().TooManyArguments, len(args), max)
 	}
 
-	if len(p.args) > 0 {
-		fmt.Printf("Optional arguments:\n")
-		for _, arg := range p.args {
-			if arg.ShortName != "" {
-				fmt.Printf("  -%s, --%-15s %s\n", arg.ShortName, arg.Name, arg.Description)
-			} else {
-				fmt.Printf("      --%-15s %s\n", arg.Name, arg.Description)
-			}
-		}
-		fmt.Printf("\n")
+	if p.defErr != nil {
+		return nil, p.defErr
+	}
+	if err := p.checkPositionalOrder(); err != nil {
+		return nil, err
+	}
+	if err := p.checkCommandConflicts(); err != nil {
+		return nil, err
 	}
 
-	if len(p.subparsers) > 0 {
-		fmt.Printf("Commands:\n")
-		for name, subparser := range p.subparsers {
-			fmt.Printf("  %-20s %s\n", name, subparser.description)
+	// Without a subcommand name first, run the default command with every
+	// token, unless the first token is one of this parser's own flags
+	implicit := 0
+	if p.defaultCmd != "" {
+		if _, ok := p.subparsers[p.defaultCmd]; !ok {
+			return nil, p.errorf(KindInvalidDefinition, p.defaultCmd, p.msgs().UnknownDefaultCommand, p.defaultCmd)
+		}
+		if len(args) == 0 || !p.isCommandOrOwnFlag(args[0]) {
+			p.tracef("default command %s", p.defaultCmd)
+			args = append([]string{p.defaultCmd}, args...)
+			implicit = 1
 		}
-		fmt.Printf("\n")
 	}
 
-	if p.epilog != "" {
-		fmt.Printf("%s\n", p.epilog)
+	// Show help instead of a missing-argument error when invoked bare
+	if len(args) == 0 && p.helpOnEmpty && p.needsArgs() {
+		p.root().requestedBy = p
+		return nil, ErrHelpRequested
 	}
-}
 
-// Helper function to parse values based on type
-func parseValue(argType ArgumentType, value string) (interface{}, error) {
+	// Initialize result map
+	result := make(map[string]interface{})
+	p.raw = make(map[string][]string)
+	p.subparser = ""
+	p.chain = nil
+
+	// Add default values and forget what a previous parse set
+	for _, arg := range p.args {
+		arg.isSet = false
+		arg.fromEnv = false
+		if arg.DefaultVal != nil {
+			result[arg.Name] = arg.DefaultVal
+		}
+	}
+
+	// Positionals are filled left to right, so optional trailing slots the
+	// user did not reach keep these defaults
+	for _, arg := range p.positional {
+		arg.isSet = false
+		arg.fromEnv = false
+		if arg.DefaultVal != nil {
+			result[arg.Name] = arg.DefaultVal
+		}
+	}
+
+	// Process arguments
+	st := &parseState{args: args, result: result}
+
+	for ; st.i < len(args); st.i++ {
+		arg := args[st.i]
+
+		// A subcommand name as the first non-flag token selects the
+		// subcommand, even if a positional could take it; after "--" or a
+		// positional it is an ordinary positional value
+		if len(p.subparsers) > 0 && !st.optionsDone && !st.positionalSeen {
+			if subparser, ok := p.subparsers[arg]; ok {
+				// Help or version asked for before the command is for this parser
+				if st.helpFlag || st.versionFlag {
+					break
+				}
+				if p.chaining {
+					return p.parseChain(args, st.i, implicit, result)
+				}
+				if err := p.checkOnlyFor(arg); err != nil {
+					return nil, err
+				}
+				p.tracef("%q: subcommand %s", arg, arg)
+				p.subparser = arg
+				subResult, err := subparser.Parse(args[st.i+1:])
+				if err != nil {
+					return nil, shiftPosition(err, st.i+1-implicit)
+				}
+
+				if err := p.applyEnv(result); err != nil {
+					return nil, err
+				}
+				result["subcommand"] = arg
+				for k, v := range subResult {
+					result[k] = v
+				}
+				for k, v := range subparser.raw {
+					p.raw[k] = v
+				}
+				return p.finish(result)
+			}
+		}
+
+		// A bare "--" ends option parsing; the rest are positionals verbatim
+		if arg == "--" && !st.optionsDone {
+			p.tracef("%q: end of options", arg)
+			st.optionsDone = true
+			continue
+		}
+
+		// Raw trailing positional takes everything that is left
+		if st.positionalIndex < len(p.positional) && p.positional[st.positionalIndex].IsRemainder {
+			pos := p.positional[st.positionalIndex]
+			if st.positionalIndex > 0 || st.optionsDone || arg == "-" || !strings.HasPrefix(arg, "-") || isNegativeNumber(arg) && !p.hasNumericShort() {
+				p.tracef("%q: remainder %s = %q", p.traceToken(st, arg), pos.Name, traceValue(pos, args[st.i:]))
+				result[pos.Name] = append([]string{}, args[st.i:]...)
+				pos.isSet = true
+				p.raw[pos.Name] = append(p.raw[pos.Name], args[st.i:]...)
+				st.positionalIndex++
+				break
+			}
+		}
+
+		var err error
+		switch {
+		case st.optionsDone:
+			p.tracef("%q: positional", p.traceToken(st, arg))
+			err = p.parsePositional(st, arg)
+		case isNegativeNumber(arg) && !p.hasNumericShort():
+			p.tracef("%q: positional", p.traceToken(st, arg))
+			err = p.parsePositional(st, arg)
+		case strings.HasPrefix(arg, "--"):
+			p.tracef("%q: long flag", p.traceToken(st, arg))
+			err = p.parseLong(st, arg)
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			p.tracef("%q: short flag", p.traceToken(st, arg))
+			err = p.parseShort(st, arg)
+		default:
+			p.tracef("%q: positional", p.traceToken(st, arg))
+			err = p.parsePositional(st, arg)
+		}
+		if err != nil {
+			return nil, atToken(err, st.i-implicit)
+		}
+	}
+
+	// Help, version and choice listings are reported to the caller, which
+	// prints them with printRequest
+	if st.helpFlag {
+		p.root().requestedBy = p
+		return nil, ErrHelpRequested
+	}
+	if st.versionFlag {
+		p.root().requestedBy = p
+		return nil, ErrVersionRequested
+	}
+	if p.choicesFlag != nil && p.choicesFlag.isSet {
+		return nil, p.requestChoices(result)
+	}
+
+	// Element validation failures of variadic positionals, all at once
+	if len(st.elementErrs) > 0 {
+		return nil, p.joinErrors(st.elementErrs)
+	}
+
+	// Environment variables fill what the command line left unset
+	if err := p.applyEnv(result); err != nil {
+		return nil, err
+	}
+	return p.finish(result)
+}
+
+// finish resolves computed defaults, runs the required, group and validator
+// checks and stores result. With a subcommand selected, result already holds
+// the subcommand's values, so checks and defaults can refer to them.
+func (p *Parser) finish(result map[string]interface{}) (map[string]interface{}, error) {
+	// Computed defaults
+	if err := p.resolveDefaultFuncs(result); err != nil {
+		return nil, err
+	}
+
+	// A terminating flag hands control to its handler without the usual checks
+	for _, parser := range p.activeParsers() {
+		if parser.terminated() {
+			return p.store(result)
+		}
+	}
+
+	// Check required arguments (only if help/version not specified).
+	// A default does not satisfy a required argument; only user input does.
+	for _, arg := range p.args {
+		if arg.IsRequired && p.missing(arg, result) {
+			if arg.isPositional {
+				return nil, p.errorf(KindMissingRequired, arg.Name, p.msgs().RequiredMissing, arg.Name).with(&MissingRequiredError{Name: arg.Name, format: p.msgs().RequiredMissing})
+			} else {
+				if arg.ShortName != "" {
+					return nil, p.errorf(KindMissingRequired, "--"+arg.Name, p.msgs().RequiredMissing, "--"+arg.Name+"/-"+arg.ShortName).with(&MissingRequiredError{Name: arg.Name, format: p.msgs().RequiredMissing})
+				} else {
+					return nil, p.errorf(KindMissingRequired, "--"+arg.Name, p.msgs().RequiredMissing, "--"+arg.Name).with(&MissingRequiredError{Name: arg.Name, format: p.msgs().RequiredMissing})
+				}
+			}
+		}
+	}
+
+	// A selected subcommand takes the place of the positionals
+	positionals := p.positional
+	if p.subparser != "" {
+		positionals = nil
+	}
+	for _, arg := range positionals {
+		if got := p.countOf(arg, result); arg.FixedCount > 0 && got < arg.FixedCount && !arg.fromEnv {
+			return nil, p.errorf(KindMissingRequired, arg.Name, p.msgs().PositionalCount, arg.Name, arg.FixedCount, got).with(&MissingRequiredError{Name: arg.Name, format: p.msgs().RequiredMissing})
+		}
+		if arg.IsRequired && p.missing(arg, result) {
+			return nil, p.errorf(KindMissingRequired, arg.Name, p.msgs().RequiredPositional, arg.Name).with(&MissingRequiredError{Name: arg.Name, format: p.msgs().RequiredMissing})
+		}
+	}
+
+	for _, group := range p.groups {
+		if err := group.check(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, req := range p.requires {
+		if err := p.checkRequirement(req, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// Cross-argument validation, flags and positionals alike
+	for _, validate := range p.validators {
+		if err := validate(result); err != nil {
+			return nil, p.validationError(err)
+		}
+	}
+
+	return p.store(result)
+}
+
+// resetCommands forgets what earlier parses stored in the subcommands
+// beneath p, so commands this parse does not select read as unset
+func (p *Parser) resetCommands() {
+	for _, subparser := range p.subparsers {
+		subparser.result = nil
+		subparser.raw = nil
+		subparser.subparser = ""
+		subparser.chain = nil
+		for _, arg := range append(append([]*Argument{}, subparser.args...), subparser.positional...) {
+			arg.isSet = false
+			arg.fromEnv = false
+		}
+		subparser.resetCommands()
+	}
+}
+
+// parseState tracks progress through the arguments during Parse
+type parseState struct {
+	args            []string
+	i               int
+	positionalIndex int
+	result          map[string]interface{}
+	helpFlag        bool
+	versionFlag     bool
+	optionsDone     bool
+	positionalSeen  bool
+	elementErrs     []error
+}
+
+// findOption returns the first accepted flag matching the predicate
+func (p *Parser) findOption(match func(option *Argument) bool) *Argument {
+	for _, option := range p.options() {
+		if match(option) {
+			return option
+		}
+	}
+	return nil
+}
+
+// parseLong handles a --name or --name=value token
+func (p *Parser) parseLong(st *parseState, arg string) error {
+	// Only the first "=" separates the name, so --define=a=b=c gives
+	// define the value "a=b=c"
+	name, value, hasValue := strings.Cut(arg[2:], "=")
+
+	display := "--" + name
+	option := p.findOption(func(option *Argument) bool { return option.Name == name })
+	if negated := p.negatedBool(name); option == nil && negated != nil {
+		if negated.parent != p && !negated.allowedIn(p.name) {
+			return p.errorf(KindNotAllowed, display, p.msgs().NotAllowedWithCommand, display, p.name)
+		}
+		st.result[negated.Name] = false
+		negated.isSet = true
+		p.raw[negated.Name] = append(p.raw[negated.Name], arg)
+		p.tracef("  %s = false", negated.Name)
+		p.redirect(negated, st.result)
+		return nil
+	}
+	if option == nil && !p.root().noAbbrev && name != "" {
+		var err error
+		if option, err = p.matchAbbrev(name); err != nil {
+			return err
+		}
+		if option != nil {
+			p.tracef("  %s abbreviates --%s", display, option.Name)
+			name, display = option.Name, "--"+option.Name
+		}
+	}
+
+	// Check for help and version flags
+	if name == "help" {
+		st.helpFlag = true
+		st.result["help"] = true
+	} else if name == "version" {
+		st.versionFlag = true
+		st.result["version"] = true
+	}
+
+	if option == nil && p.root().tolerant {
+		p.skipUnknown(st, display, hasValue)
+		return nil
+	}
+	if option == nil {
+		return p.errorf(KindUnknownArgument, display, p.msgs().UnknownArgument, display).with(&UnknownArgumentError{Name: display, format: p.msgs().UnknownArgument})
+	}
+	if option.parent != p && !option.allowedIn(p.name) {
+		return p.errorf(KindNotAllowed, display, p.msgs().NotAllowedWithCommand, display, p.name)
+	}
+	return p.applyOption(st, option, display, arg, value, hasValue)
+}
+
+// negatedBool returns the Bool flag that name turns off in its --no-NAME
+// form, or nil. A flag registered under the full name takes precedence.
+func (p *Parser) negatedBool(name string) *Argument {
+	target, ok := strings.CutPrefix(name, "no-")
+	if !ok {
+		return nil
+	}
+	return p.findOption(func(option *Argument) bool {
+		return option.Name == target && option.negatable()
+	})
+}
+
+// negatable reports whether a flag also accepts a --no-NAME form
+func (a *Argument) negatable() bool {
+	return a.ArgType == Bool && a.Name != "" && a.Name != "help" && a.Name != "version"
+}
+
+// AllowAbbrev controls whether a long option may be given as an unambiguous
+// prefix of its name, such as --verb for --verbose. It is enabled by default;
+// an exact name always wins over a prefix.
+func (p *Parser) AllowAbbrev(enable bool) *Parser {
+	p.noAbbrev = !enable
+	return p
+}
+
+// matchAbbrev returns the only accepted long option starting with prefix, nil
+// if there is none, or an error if several do
+func (p *Parser) matchAbbrev(prefix string) (*Argument, error) {
+	var matches []*Argument
+	for _, option := range p.options() {
+		if strings.HasPrefix(option.Name, prefix) && (option.parent == p || option.allowedIn(p.name)) {
+			matches = append(matches, option)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	}
+
+	names := make([]string, len(matches))
+	for i, option := range matches {
+		names[i] = "--" + option.Name
+	}
+	display := "--" + prefix
+	return nil, p.errorf(KindAmbiguousOption, display, p.msgs().AmbiguousOption, display, strings.Join(names, ", "))
+}
+
+// parseShort handles a -x token or a cluster such as -abc or -nvalue
+func (p *Parser) parseShort(st *parseState, arg string) error {
+	shortName := arg[1:]
+
+	// Check for help and version flags
+	if shortName == "h" {
+		st.helpFlag = true
+		st.result["help"] = true
+	} else if shortName == "V" {
+		st.versionFlag = true
+		st.result["version"] = true
+	}
+
+	// Handle multiple short options (e.g., -abc)
+	for j, shortOpt := range shortName {
+		display := "-" + string(shortOpt)
+		option := p.findOption(func(option *Argument) bool { return option.ShortName == string(shortOpt) })
+		if option == nil && p.root().tolerant {
+			// The rest of the cluster may be its value, so it is dropped too
+			p.skipUnknown(st, display, j+utf8.RuneLen(shortOpt) < len(shortName))
+			return nil
+		}
+		if option == nil {
+			return p.errorf(KindUnknownArgument, display, p.msgs().UnknownArgument, display).with(&UnknownArgumentError{Name: display, format: p.msgs().UnknownArgument})
+		}
+		if option.parent != p && !option.allowedIn(p.name) {
+			return p.errorf(KindNotAllowed, display, p.msgs().NotAllowedWithCommand, display, p.name)
+		}
+
+		if option.ArgType == Bool || option.ArgType == Counter {
+			if err := p.applyOption(st, option, display, display, "", false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// A value-taking option uses the rest of the cluster as its value;
+		// -o=-5 is accepted like --offset=-5
+		if rest := shortName[j+utf8.RuneLen(shortOpt):]; rest != "" {
+			return p.applyOption(st, option, display, display, strings.TrimPrefix(rest, "="), true)
+		}
+		return p.applyOption(st, option, display, display, "", false)
+	}
+	return nil
+}
+
+// TolerateUnknown makes Parse warn about unknown flags and skip them, with
+// their value, instead of failing, so older binaries accept newer flags
+func (p *Parser) TolerateUnknown(enable bool) *Parser {
+	p.tolerant = enable
+	return p
+}
+
+// skipUnknown warns about an unknown flag and skips the value following it,
+// unless the value was attached to the flag
+func (p *Parser) skipUnknown(st *parseState, display string, hasValue bool) {
+	fmt.Fprintf(p.errorOutput(), p.msgs().UnknownIgnored+"\n", display)
+	p.tracef("  %s ignored", display)
+	if !hasValue && st.i+1 < len(st.args) && !strings.HasPrefix(st.args[st.i+1], "-") {
+		st.i++
+	}
+}
+
+// applyOption records a flag occurrence. Value-taking flags use the attached
+// value if there is one, otherwise they consume the following token(s).
+func (p *Parser) applyOption(st *parseState, option *Argument, display, token, value string, hasValue bool) error {
+	result := st.result
+
+	switch option.ArgType {
+	case Bool:
+		result[option.Name] = true
+		option.isSet = true
+		p.raw[option.Name] = append(p.raw[option.Name], token)
+		p.tracef("  %s = true", option.Name)
+		p.redirect(option, result)
+		return nil
+
+	case Counter:
+		count, _ := result[option.Name].(int)
+		result[option.Name] = count + 1
+		option.isSet = true
+		p.raw[option.Name] = append(p.raw[option.Name], token)
+		p.tracef("  %s = %d", option.Name, count+1)
+		p.redirect(option, result)
+		return nil
+	}
+
+	tokens := []string{value}
+	if !hasValue {
+		if strings.HasPrefix(display, "--") && p.root().requireEq {
+			return p.errorf(KindMissingValue, display, p.msgs().RequiresEquals, display, display)
+		}
+		args := st.args
+		if st.i+1 >= len(args) || strings.HasPrefix(args[st.i+1], "-") && !(option.numeric() && isNegativeNumber(args[st.i+1])) {
+			return p.errorf(KindMissingValue, display, p.msgs().RequiresValue, display)
+		}
+		tokens = valueTokens(option, args, st.i)
+		st.i += len(tokens)
+		value = joinTokens(tokens)
+	}
+
+	value, parsedValue, err := option.convert(value)
+	if err != nil {
+		return p.errorf(KindInvalidValue, display, p.msgs().InvalidValue, display, err).
+			with(&InvalidValueError{Name: display, Value: value, Err: err, format: p.msgs().InvalidValue})
+	}
+	if err := p.checkChoices(option, display, value); err != nil {
+		return err
+	}
+	if err := p.checkRange(option, display, value, parsedValue); err != nil {
+		return err
+	}
+	if err := option.validate(parsedValue); err != nil {
+		return p.errorf(KindInvalidValue, display, p.msgs().InvalidValue, display, err).
+			with(&InvalidValueError{Name: display, Value: value, Err: err, format: p.msgs().InvalidValue})
+	}
+	p.setValue(option, result, parsedValue)
+	p.raw[option.Name] = append(p.raw[option.Name], tokens...)
+	p.redirect(option, result)
+	return nil
+}
+
+// applyEnv fills arguments not given on the command line from their
+// environment variables, parsed and checked like command-line values
+func (p *Parser) applyEnv(result map[string]interface{}) error {
+	for _, arg := range append(append([]*Argument{}, p.args...), p.positional...) {
+		if arg.EnvVar == "" || arg.isSet {
+			continue
+		}
+		value, ok := os.LookupEnv(arg.EnvVar)
+		if !ok {
+			continue
+		}
+		display := arg.Name + " ($" + arg.EnvVar + ")"
+		if !arg.isPositional {
+			display = "--" + display
+		}
+		value, parsedValue, err := arg.convert(value)
+		if err != nil {
+			return p.errorf(KindInvalidValue, display, p.msgs().InvalidValue, display, err).
+				with(&InvalidValueError{Name: display, Value: value, Err: err, format: p.msgs().InvalidValue})
+		}
+		if err := p.checkChoices(arg, display, value); err != nil {
+			return err
+		}
+		if err := p.checkRange(arg, display, value, parsedValue); err != nil {
+			return err
+		}
+		if err := arg.validate(parsedValue); err != nil {
+			return p.errorf(KindInvalidValue, display, p.msgs().InvalidValue, display, err).
+				with(&InvalidValueError{Name: display, Value: value, Err: err, format: p.msgs().InvalidValue})
+		}
+		result[arg.Name] = parsedValue
+		arg.fromEnv = true
+		p.tracef("$%s: %s = %v", arg.EnvVar, arg.Name, traceValue(arg, parsedValue))
+	}
+	return nil
+}
+
+// redirect warns about a deprecated flag and copies its value to the
+// replacement named by DeprecatedInFavorOf
+func (p *Parser) redirect(option *Argument, result map[string]interface{}) {
+	if option.ReplacedBy == "" {
+		return
+	}
+	fmt.Fprintf(p.errorOutput(), p.msgs().DeprecatedFlag+"\n", "--"+option.Name, "--"+option.ReplacedBy)
+	replacement := p.findOption(func(arg *Argument) bool { return arg.Name == option.ReplacedBy })
+	if replacement == nil {
+		return
+	}
+	result[replacement.Name] = result[option.Name]
+	replacement.isSet = true
+	p.tracef("  %s = %v", replacement.Name, traceValue(replacement, result[replacement.Name]))
+}
+
+// checkChoices reports a value outside the argument's ValidChoices, comparing
+// the value as typed so it matches the documented choices. List values are
+// checked element by element; FeatureSet names are checked by parseValue.
+func (p *Parser) checkChoices(arg *Argument, display, value string) error {
+	if arg.ChoicesFunc == nil && arg.choiceRange != nil {
+		if arg.hasChoice(value) {
+			return nil
+		}
+		label := arg.rangeLabel()
+		return p.errorf(KindInvalidValue, display, p.msgs().InvalidChoice, display, value, label).
+			with(&InvalidChoiceError{Name: display, Value: value, Choices: []string{label}, format: p.msgs().InvalidChoice})
+	}
+	choices := arg.choices()
+	if len(choices) == 0 || arg.ArgType == FeatureSet {
+		return nil
+	}
+	values := []string{value}
+	if arg.ArgType == List && arg.CustomType == "" {
+		values = strings.Split(value, arg.separator())
+	}
+	for _, v := range values {
+		if !containsString(choices, v) {
+			return p.errorf(KindInvalidValue, display, p.msgs().InvalidChoice, display, v, strings.Join(choices, ", ")).
+				with(&InvalidChoiceError{Name: display, Value: v, Choices: choices, format: p.msgs().InvalidChoice})
+		}
+	}
+	return nil
+}
+
+// checkRange reports a numeric value outside the argument's Min and Max
+func (p *Parser) checkRange(arg *Argument, display, value string, parsed interface{}) error {
+	if arg.MinValue == nil && arg.MaxValue == nil {
+		return nil
+	}
+	var n float64
+	switch v := parsed.(type) {
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case uint64:
+		n = float64(v)
+	case float32:
+		n = float64(v)
+	case float64:
+		n = v
+	default:
+		return nil
+	}
+	if (arg.MinValue == nil || n >= *arg.MinValue) && (arg.MaxValue == nil || n <= *arg.MaxValue) {
+		return nil
+	}
+
+	lo, hi := "-inf", "+inf"
+	if arg.MinValue != nil {
+		lo = strconv.FormatFloat(*arg.MinValue, 'g', -1, 64)
+	}
+	if arg.MaxValue != nil {
+		hi = strconv.FormatFloat(*arg.MaxValue, 'g', -1, 64)
+	}
+	err := p.errorf(KindInvalidValue, display, p.msgs().OutOfRange, value, display, lo, hi)
+	return err.with(&InvalidValueError{Name: display, Value: value, Err: errors.New(err.Message), format: p.msgs().InvalidValue})
+}
+
+// parsePositional fills the next positional slot with arg
+func (p *Parser) parsePositional(st *parseState, arg string) error {
+	if st.positionalIndex >= len(p.positional) {
+		return p.errorf(KindUnexpectedPositional, arg, p.msgs().UnrecognizedPositional, arg)
+	}
+
+	st.positionalSeen = true
+	pos := p.positional[st.positionalIndex]
+	value := arg
+	if !st.optionsDone {
+		value = unescape(arg)
+	}
+	value, parsedValue, err := pos.convert(value)
+	if err != nil {
+		return p.errorf(KindInvalidValue, pos.Name, p.msgs().InvalidValue, pos.Name, err).
+			with(&InvalidValueError{Name: pos.Name, Value: value, Err: err, format: p.msgs().InvalidValue})
+	}
+	if err := p.checkChoices(pos, pos.Name, value); err != nil {
+		return err
+	}
+	if err := p.checkRange(pos, pos.Name, value, parsedValue); err != nil {
+		return err
+	}
+	p.raw[pos.Name] = append(p.raw[pos.Name], arg)
+
+	if err := pos.validate(parsedValue); err != nil {
+		verr := p.errorf(KindInvalidValue, pos.Name, p.msgs().InvalidValue, pos.Name+" "+strconv.Quote(value), err).
+			with(&InvalidValueError{Name: pos.Name, Value: value, Err: err, format: p.msgs().InvalidValue})
+		if !pos.IsVariadic {
+			return verr
+		}
+		st.elementErrs = append(st.elementErrs, verr)
+	}
+
+	// A variadic positional stays the current slot and keeps collecting; a
+	// counted one moves on once it has its values
+	if pos.collects() {
+		if !pos.isSet {
+			st.result[pos.Name] = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(parsedValue)), 0, 1).Interface()
+		}
+		slice := reflect.Append(reflect.ValueOf(st.result[pos.Name]), reflect.ValueOf(parsedValue))
+		st.result[pos.Name] = slice.Interface()
+		pos.isSet = true
+		p.tracef("  %s = %v", pos.Name, traceValue(pos, st.result[pos.Name]))
+		if pos.FixedCount > 0 && slice.Len() == pos.FixedCount {
+			st.positionalIndex++
+		}
+		return nil
+	}
+
+	st.result[pos.Name] = parsedValue
+	pos.isSet = true
+	p.tracef("  %s = %v", pos.Name, traceValue(pos, parsedValue))
+	st.positionalIndex++
+	return nil
+}
+
+// TreatEmptyAsUnset makes an empty value for any required argument fail
+// the required check, as if the argument had not been given
+func (p *Parser) TreatEmptyAsUnset(enable bool) *Parser {
+	p.emptyUnset = enable
+	return p
+}
+
+// missing reports whether an argument counts as not given: neither on the
+// command line nor in the environment, or given empty when that is unset
+func (p *Parser) missing(arg *Argument, result map[string]interface{}) bool {
+	if !arg.isSet && !arg.fromEnv {
+		return true
+	}
+	if arg.EmptyIsUnset || p.root().emptyUnset {
+		if s, ok := result[arg.Name].(string); ok && s == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// countOf returns how many values a collecting positional received
+func (p *Parser) countOf(arg *Argument, result map[string]interface{}) int {
+	if !arg.isSet {
+		return 0
+	}
+	if v := reflect.ValueOf(result[arg.Name]); v.Kind() == reflect.Slice {
+		return v.Len()
+	}
+	return 1
+}
+
+// terminated reports whether a terminating flag was given
+func (p *Parser) terminated() bool {
+	for _, option := range p.options() {
+		if option.IsTerminator && option.isSet {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPositionalOrder reports a required positional declared after an
+// optional one, which could never be filled unambiguously, or after a
+// variadic one, which takes every remaining token and leaves it none
+func (p *Parser) checkPositionalOrder() error {
+	var optional, variadic *Argument
+	for _, pos := range p.positional {
+		if pos.IsRemainder {
+			continue
+		}
+		if pos.IsRequired && variadic != nil {
+			return p.errorf(KindInvalidDefinition, pos.Name, p.msgs().VariadicOrder, pos.Name, variadic.Name)
+		}
+		if !pos.IsRequired {
+			if optional == nil {
+				optional = pos
+			}
+		} else if optional != nil {
+			return p.errorf(KindInvalidDefinition, pos.Name, p.msgs().PositionalOrder, pos.Name, optional.Name)
+		}
+		if pos.IsVariadic && variadic == nil {
+			variadic = pos
+		}
+	}
+	return nil
+}
+
+// checkCommandConflicts reports a first positional whose choices include a
+// subcommand name, which could never be given as the positional's value
+func (p *Parser) checkCommandConflicts() error {
+	if len(p.positional) == 0 || len(p.subparsers) == 0 {
+		return nil
+	}
+	pos := p.positional[0]
+	for _, name := range p.commands {
+		if pos.hasChoice(name) {
+			return p.errorf(KindInvalidDefinition, pos.Name, p.msgs().CommandConflict, pos.Name, name)
+		}
+	}
+	return nil
+}
+
+// resolveDefaultFuncs evaluates DefaultFunc for unset arguments in dependency order
+func (p *Parser) resolveDefaultFuncs(result map[string]interface{}) error {
+	byName := make(map[string]*Argument)
+	for _, arg := range p.args {
+		byName[arg.Name] = arg
+	}
+	for _, arg := range p.positional {
+		byName[arg.Name] = arg
+	}
+
+	// 0 = pending, 1 = resolving, 2 = done
+	state := make(map[string]int)
+	var resolve func(arg *Argument) error
+	resolve = func(arg *Argument) error {
+		switch state[arg.Name] {
+		case 1:
+			return p.errorf(KindInvalidDefinition, arg.Name, p.msgs().DefaultCycle, arg.Name)
+		case 2:
+			return nil
+		}
+		state[arg.Name] = 1
+		for _, dep := range arg.DefaultDeps {
+			if depArg, ok := byName[dep]; ok {
+				if err := resolve(depArg); err != nil {
+					return err
+				}
+			}
+		}
+		// A nil result keeps the static DefaultVal, if any
+		if arg.DefaultFunc != nil && !arg.isSet && !arg.fromEnv {
+			if value := arg.DefaultFunc(result); value != nil {
+				result[arg.Name] = value
+			}
+		}
+		state[arg.Name] = 2
+		return nil
+	}
+
+	for _, arg := range p.args {
+		if err := resolve(arg); err != nil {
+			return err
+		}
+	}
+	for _, arg := range p.positional {
+		if err := resolve(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseOrExit parses command line arguments or exits on error
+func (p *Parser) ParseOrExit() map[string]interface{} {
+	result, err := p.Parse(nil)
+	if p.printRequest(err) {
+		p.exit(p.requestExitCode(err))
+		return nil
+	}
+	if err != nil {
+		p.printError(err)
+		p.exit(p.exitCode(err))
+	}
+	return result
+}
+
+// requestExitCode returns the exit code after printing a request: the
+// SetHelpExitCode code for help, 0 otherwise
+func (p *Parser) requestExitCode(err error) int {
+	if errors.Is(err, ErrHelpRequested) {
+		return p.root().helpExit
+	}
+	return 0
+}
+
+// printRequest prints the help, version or choice listing err asks for, for
+// the command it was requested on, and reports whether err was such a request
+func (p *Parser) printRequest(err error) bool {
+	target := p.root().requestedBy
+	if target == nil {
+		target = p
+	}
+	switch {
+	case errors.Is(err, ErrHelpRequested):
+		target.PrintHelp()
+	case errors.Is(err, ErrVersionRequested):
+		target.PrintVersion()
+	case errors.Is(err, ErrChoicesListed):
+		target.printChoices()
+	default:
+		return false
+	}
+	return true
+}
+
+// PrintVersion prints the program name and version
+func (p *Parser) PrintVersion() {
+	fmt.Fprintf(p.output(), "%s %s\n", p.name, p.version)
+}
+
+// valueTokens returns the tokens forming the value after the flag at args[i].
+// Greedy string arguments take every token up to the next flag.
+func valueTokens(option *Argument, args []string, i int) []string {
+	tokens := []string{args[i+1]}
+	if option.IsGreedy && option.ArgType == String {
+		for j := i + 2; j < len(args) && !strings.HasPrefix(args[j], "-"); j++ {
+			tokens = append(tokens, args[j])
+		}
+	}
+	return tokens
+}
+
+// joinTokens unescapes value tokens and joins them with spaces
+func joinTokens(tokens []string) string {
+	values := make([]string, len(tokens))
+	for i, token := range tokens {
+		values[i] = unescape(token)
+	}
+	return strings.Join(values, " ")
+}
+
+// setValue stores a parsed flag value, merging repeated feature sets
+func (p *Parser) setValue(option *Argument, result map[string]interface{}, value interface{}) {
+	if set, ok := value.(map[string]bool); ok && option.ArgType == FeatureSet && option.isSet {
+		merged := make(map[string]bool)
+		for name := range result[option.Name].(map[string]bool) {
+			merged[name] = true
+		}
+		for name := range set {
+			merged[name] = true
+		}
+		value = merged
+	}
+	if option.IsAppend {
+		value = appendValue(option, result, value)
+	}
+	result[option.Name] = value
+	option.isSet = true
+	p.tracef("  %s = %v", option.Name, traceValue(option, value))
+}
+
+// appendValue adds value to the values an append-mode flag collected so far.
+// Lists are concatenated; other types collect into a slice of their type.
+func appendValue(option *Argument, result map[string]interface{}, value interface{}) interface{} {
+	if list, ok := value.([]string); ok && option.ArgType == List {
+		if !option.isSet {
+			return list
+		}
+		return append(append([]string{}, result[option.Name].([]string)...), list...)
+	}
+	if !option.isSet {
+		result[option.Name] = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(value)), 0, 1).Interface()
+	}
+	return reflect.Append(reflect.ValueOf(result[option.Name]), reflect.ValueOf(value)).Interface()
+}
+
+// isNegativeNumber reports whether s looks like a negative number such as
+// -5 or -1.5, which is read as a value rather than a flag
+func isNegativeNumber(s string) bool {
+	if len(s) < 2 || s[0] != '-' {
+		return false
+	}
+	digits, dots := 0, 0
+	for _, r := range s[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '.':
+			dots++
+		default:
+			return false
+		}
+	}
+	return digits > 0 && dots <= 1
+}
+
+// numeric reports whether the argument takes a number
+func (a *Argument) numeric() bool {
+	switch a.ArgType {
+	case Int, Int64, Uint, Float, Float32:
+		return a.CustomType == ""
+	}
+	return false
+}
+
+// hasNumericShort reports whether a flag has a digit as its short name, in
+// which case a token like -5 is that flag rather than a negative number
+func (p *Parser) hasNumericShort() bool {
+	return p.findOption(func(option *Argument) bool {
+		return len(option.ShortName) == 1 && option.ShortName[0] >= '0' && option.ShortName[0] <= '9'
+	}) != nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// unescape strips the backslash from a token like \--help, which is how a
+// literal value starting with a dash is passed without being read as a flag
+func unescape(token string) string {
+	if strings.HasPrefix(token, "\\-") {
+		return token[1:]
+	}
+	return token
+}
+
+var (
+	typeRegistryMu sync.RWMutex
+	typeRegistry   = make(map[string]func(string) (interface{}, error))
+)
+
+// RegisterType registers a parse function under a name that arguments can
+// reference through their CustomType field
+func RegisterType(name string, parse func(string) (interface{}, error)) {
+	typeRegistryMu.Lock()
+	defer typeRegistryMu.Unlock()
+	typeRegistry[name] = parse
+}
+
+// Type sets a registered custom type for the argument
+func (a *Argument) Type(name string) *Argument {
+	a.CustomType = name
+	return a
+}
+
+// Pipe adds steps that transform the value as typed before it is converted
+// and checked against the choices, such as trimming or lowercasing. Steps run
+// in order, after any earlier ones, and the first error rejects the value.
+func (a *Argument) Pipe(steps ...func(value string) (string, error)) *Argument {
+	a.Pipeline = append(a.Pipeline, steps...)
+	return a
+}
+
+// convert runs the pipeline on a value and converts the result, returning
+// the transformed value along with the converted one
+func (a *Argument) convert(value string) (string, interface{}, error) {
+	for _, step := range a.Pipeline {
+		transformed, err := step(value)
+		if err != nil {
+			return value, nil, err
+		}
+		value = transformed
+	}
+	parsed, err := a.parseValue(value)
+	return value, parsed, err
+}
+
+// parseValue converts a token using the argument's custom type if set,
+// otherwise its built-in type
+func (a *Argument) parseValue(value string) (interface{}, error) {
+	m := a.parent.msgs()
+	if a.Units != nil {
+		return a.parseUnit(value)
+	}
+	if a.ArgType == List && a.Separator != "" && a.CustomType == "" {
+		return strings.Split(value, a.Separator), nil
+	}
+	if a.ArgType == DateTime && len(a.Layouts) > 0 && a.CustomType == "" {
+		return parseDateTime(value, a.Layouts, m)
+	}
+	if a.CustomType == "" {
+		parsed, err := parseValue(a.ArgType, value, m)
+		if choices := a.choices(); err == nil && a.ArgType == FeatureSet && len(choices) > 0 {
+			for name := range parsed.(map[string]bool) {
+				if !containsString(choices, name) {
+					return nil, fmt.Errorf(m.UnknownFeature, name, strings.Join(choices, ", "))
+				}
+			}
+		}
+		if err == nil && a.ArgType == Glob && a.MustMatch && len(parsed.([]string)) == 0 {
+			return nil, fmt.Errorf(m.NoFilesMatch, value)
+		}
+		return parsed, err
+	}
+
+	typeRegistryMu.RLock()
+	parse, ok := typeRegistry[a.CustomType]
+	typeRegistryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(m.UnknownType, a.CustomType)
+	}
+	return parse(value)
+}
+
+// parseUnit converts a value like 5km to the base unit
+func (a *Argument) parseUnit(value string) (interface{}, error) {
+	end := len(value)
+	for i, r := range value {
+		if !(r >= '0' && r <= '9' || r == '.' || r == '-' || r == '+') {
+			end = i
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(value[:end], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := strings.TrimSpace(value[end:])
+	if suffix == "" || suffix == a.BaseUnit {
+		return number, nil
+	}
+	multiplier, ok := a.Units[suffix]
+	if !ok {
+		units := make([]string, 0, len(a.Units)+1)
+		units = append(units, a.BaseUnit)
+		for unit := range a.Units {
+			if unit != a.BaseUnit {
+				units = append(units, unit)
+			}
+		}
+		sort.Strings(units[1:])
+		return nil, fmt.Errorf(a.parent.msgs().UnknownUnit, suffix, strings.Join(units, ", "))
+	}
+	return number * multiplier, nil
+}
+
+// Helper function to parse values based on type, reporting errors with m
+func parseValue(argType ArgumentType, value string, m *Messages) (interface{}, error) {
 	switch argType {
 	case String:
 		return value, nil
@@ -593,39 +1935,212 @@ func parseValue(argType ArgumentType, value string) (interface{}, error) {
 	case Float:
 		return strconv.ParseFloat(value, 64)
 
+	case Float32:
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, err
+		}
+		return float32(f), nil
+
+	case Int64:
+		return strconv.ParseInt(value, 10, 64)
+
+	case Uint:
+		if strings.HasPrefix(value, "-") {
+			return nil, fmt.Errorf(m.NegativeUnsigned, value)
+		}
+		return strconv.ParseUint(value, 10, 64)
+
 	case Bool:
 		return strconv.ParseBool(value)
 
 	case List:
 		return strings.Split(value, ","), nil
 
-	case DateTime:
-		// Try common date formats
-		formats := []string{
-			time.RFC3339,
-			"2006-01-02",
-			"2006-01-02 15:04:05",
-			"01/02/2006",
-			"01/02/2006 15:04:05",
-		}
-
-		for _, format := range formats {
-			t, err := time.Parse(format, value)
-			if err == nil {
-				return t, nil
+	case FeatureSet:
+		set := make(map[string]bool)
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				set[name] = true
 			}
 		}
-		return nil, errors.New("invalid datetime format")
+		return set, nil
+
+	case Duration:
+		return time.ParseDuration(value)
+
+	case TimeOfDay:
+		return parseTimeOfDay(value, m)
+
+	case Glob:
+		matches, err := filepath.Glob(value)
+		if err != nil {
+			return nil, err
+		}
+		if matches == nil {
+			matches = make([]string, 0)
+		}
+		return matches, nil
+
+	case JSON:
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return nil, fmt.Errorf(m.InvalidJSON, err)
+		}
+		return decoded, nil
+
+	case DateTime:
+		return parseDateTime(value, defaultDateLayouts, m)
 
 	default:
 		return value, nil
 	}
 }
 
+// parseTimeOfDay parses HH:MM or HH:MM:SS into the duration since midnight
+func parseTimeOfDay(value string, m *Messages) (interface{}, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf(m.InvalidTimeOfDay, value)
+	}
+	limits := []struct {
+		max  int
+		unit time.Duration
+	}{{23, time.Hour}, {59, time.Minute}, {59, time.Second}}
+
+	var total time.Duration
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || len(part) == 0 || len(part) > 2 {
+			return nil, fmt.Errorf(m.InvalidTimeOfDay, value)
+		}
+		if n < 0 || n > limits[i].max {
+			return nil, fmt.Errorf(m.TimeOfDayRange, value, n, limits[i].max)
+		}
+		total += time.Duration(n) * limits[i].unit
+	}
+	return total, nil
+}
+
+// formatTimeOfDay formats a duration since midnight as HH:MM:SS
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second))
+}
+
+// LayoutUnix is a DateTime layout accepting seconds since the Unix epoch
+const LayoutUnix = "unix"
+
+// defaultDateLayouts are the layouts DateTime arguments try by default
+var defaultDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"01/02/2006",
+	"01/02/2006 15:04:05",
+}
+
+// Layout sets the time layouts a DateTime argument accepts, such as
+// "02-Jan-2006" or LayoutUnix, tried in order in place of the defaults
+func (a *Argument) Layout(layouts ...string) *Argument {
+	a.Layouts = layouts
+	return a
+}
+
+// parseDateTime parses value with the first matching layout
+func parseDateTime(value string, layouts []string, m *Messages) (interface{}, error) {
+	for _, layout := range layouts {
+		if layout == LayoutUnix {
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return time.Unix(seconds, 0).UTC(), nil
+			}
+			continue
+		}
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf(m.InvalidDateTime, strings.Join(layouts, ", "))
+}
+
 // Get retrieves the value of an argument by name
 func (p *Parser) Get(name string) interface{} {
-	args, _ := p.Parse(nil)
-	return args[name]
+	return p.results()[name]
+}
+
+// results returns the values of the last parse. If nothing has been parsed
+// yet, the command line is parsed once from the root parser. A selected
+// subcommand reads the root's merged result, so its getters see every value.
+func (p *Parser) results() map[string]interface{} {
+	root := p.root()
+	if !root.parsed && !p.parsed {
+		root.Parse(nil)
+	}
+	// A chained command reads its own values and the shared ones
+	if p.inChain() {
+		return p.result
+	}
+	if root.parsed {
+		for parser := root; parser != nil; parser = parser.subparsers[parser.subparser] {
+			if parser == p {
+				return root.result
+			}
+			if parser.subparser == "" {
+				break
+			}
+		}
+	}
+	return p.result
+}
+
+// ProvidedArgs returns the names of the arguments given on the command line,
+// excluding defaulted ones, in registration order. Arguments of the selected
+// subcommand follow those of its parent.
+func (p *Parser) ProvidedArgs() []string {
+	p.results()
+
+	names := make([]string, 0)
+	for _, parser := range p.activeParsers() {
+		for _, arg := range parser.args {
+			if arg.isSet {
+				names = append(names, arg.Name)
+			}
+		}
+		for _, arg := range parser.positional {
+			if arg.isSet {
+				names = append(names, arg.Name)
+			}
+		}
+	}
+	return names
+}
+
+// IsSet reports whether the last parse took the argument's value from the
+// command line or the environment rather than from a default
+func (p *Parser) IsSet(name string) bool {
+	p.results()
+	if arg := p.lookup(name); arg != nil {
+		return arg.isSet || arg.fromEnv
+	}
+	return false
+}
+
+// lookup returns the argument called name among those of p and the
+// subcommands the last parse selected
+func (p *Parser) lookup(name string) *Argument {
+	for _, parser := range p.activeParsers() {
+		for _, arg := range append(parser.options(), parser.positional...) {
+			if arg.Name == name {
+				return arg
+			}
+		}
+	}
+	return nil
+}
+
+// GetRaw retrieves the tokens the user typed for an argument before conversion
+func (p *Parser) GetRaw(name string) []string {
+	p.results()
+	return p.raw[name]
 }
 
 // GetString retrieves the string value of an argument
@@ -652,6 +2167,32 @@ func (p *Parser) GetInt(name string) int {
 	return 0
 }
 
+// GetInt64 retrieves the value of an Int64 argument
+func (p *Parser) GetInt64(name string) int64 {
+	switch v := p.Get(name).(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	}
+	return 0
+}
+
+// GetUint retrieves the value of a Uint argument
+func (p *Parser) GetUint(name string) uint64 {
+	switch v := p.Get(name).(type) {
+	case uint64:
+		return v
+	case uint:
+		return uint64(v)
+	case int:
+		if v >= 0 {
+			return uint64(v)
+		}
+	}
+	return 0
+}
+
 // GetFloat retrieves the float value of an argument
 func (p *Parser) GetFloat(name string) float64 {
 	val := p.Get(name)
@@ -664,6 +2205,14 @@ func (p *Parser) GetFloat(name string) float64 {
 	return 0
 }
 
+// GetFloat32 retrieves the value of a Float32 argument
+func (p *Parser) GetFloat32(name string) float32 {
+	if f, ok := p.Get(name).(float32); ok {
+		return f
+	}
+	return 0
+}
+
 // GetBool retrieves the bool value of an argument
 func (p *Parser) GetBool(name string) bool {
 	val := p.Get(name)
@@ -688,6 +2237,58 @@ func (p *Parser) GetList(name string) []string {
 	return []string{}
 }
 
+// GetListString retrieves the values of a list argument joined by its
+// separator, as they would be typed
+func (p *Parser) GetListString(name string) string {
+	separator := ","
+	if arg := p.lookup(name); arg != nil {
+		separator = arg.separator()
+	}
+	return strings.Join(p.GetList(name), separator)
+}
+
+// GetStringSlice retrieves the list value of an argument; it is the same as GetList
+func (p *Parser) GetStringSlice(name string) []string {
+	return p.GetList(name)
+}
+
+// GetIntSlice retrieves the values of an append-mode Int argument
+func (p *Parser) GetIntSlice(name string) []int {
+	val := p.Get(name)
+	if list, ok := val.([]int); ok {
+		return list
+	}
+	return []int{}
+}
+
+// GetJSON retrieves the decoded value of a JSON argument
+func (p *Parser) GetJSON(name string) interface{} {
+	return p.Get(name)
+}
+
+// GetStringSet retrieves the set value of a feature set argument
+func (p *Parser) GetStringSet(name string) map[string]bool {
+	val := p.Get(name)
+	if set, ok := val.(map[string]bool); ok {
+		return set
+	}
+	return map[string]bool{}
+}
+
+// GetDuration retrieves the value of a duration argument
+func (p *Parser) GetDuration(name string) time.Duration {
+	if d, ok := p.Get(name).(time.Duration); ok {
+		return d
+	}
+	return 0
+}
+
+// GetTimeOfDay retrieves the value of a TimeOfDay argument as the duration
+// since midnight; add it to a date's midnight to get a time.Time
+func (p *Parser) GetTimeOfDay(name string) time.Duration {
+	return p.GetDuration(name)
+}
+
 // GetDateTime retrieves the datetime value of an argument
 func (p *Parser) GetDateTime(name string) time.Time {
 	val := p.Get(name)
@@ -0,0 +1,1454 @@
+package argparse
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubcommandRunsParentChecks(t *testing.T) {
+	newParser := func() *Parser {
+		p := NewParser("t", "")
+		p.String("", "project", nil).Persistent()
+		p.String("", "workdir", nil).DefaultFrom(func(values map[string]interface{}) interface{} {
+			if project, ok := values["project"].(string); ok && project != "" {
+				return project + "/work"
+			}
+			return nil
+		}, "project")
+		p.String("", "username", nil).Persistent()
+		p.String("", "password", nil).Persistent()
+		p.RequireTogether("username", "password")
+		p.NewExactlyOneGroup().Add(p.Bool("", "json", nil), p.Bool("", "text", nil))
+		p.AddValidator(func(values map[string]interface{}) error {
+			if values["user"] == "root" {
+				return errors.New("root is not allowed")
+			}
+			return nil
+		})
+		sub := p.NewCommand("sub", "").Parser
+		sub.String("", "user", nil)
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+		workdir interface{}
+	}{
+		{"computed default", []string{"--json", "sub", "--user", "x", "--project", "/p"}, false, "/p/work"},
+		{"require together", []string{"--json", "--username", "u", "sub"}, true, nil},
+		{"require together after command", []string{"--json", "sub", "--username", "u", "--password", "p"}, false, nil},
+		{"group", []string{"sub", "--user", "x"}, true, nil},
+		{"validator", []string{"--text", "sub", "--user", "root"}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newParser()
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && p.Get("workdir") != tt.workdir {
+				t.Errorf("workdir = %v, want %v", p.Get("workdir"), tt.workdir)
+			}
+		})
+	}
+}
+
+func TestSubcommandSkipsParentPositionals(t *testing.T) {
+	p := NewParser("t", "")
+	p.Positional("file", nil).Required()
+	p.NewCommand("sub", "")
+
+	if _, err := p.Parse([]string{"sub"}); err != nil {
+		t.Fatalf("Parse(sub) error = %v", err)
+	}
+	if _, err := p.Parse([]string{}); err == nil {
+		t.Fatal("Parse() without the positional succeeded")
+	}
+}
+
+func TestUnselectedCommandForgetsValues(t *testing.T) {
+	p := NewParser("t", "")
+	add := p.NewCommand("add", "").Parser
+	add.String("t", "title", nil)
+	p.NewCommand("list", "")
+
+	if _, err := p.Parse([]string{"add", "-t", "x"}); err != nil {
+		t.Fatalf("Parse(add) error = %v", err)
+	}
+	if got := add.GetString("title"); got != "x" {
+		t.Fatalf("title after add = %q, want %q", got, "x")
+	}
+	if _, err := p.Parse([]string{"list"}); err != nil {
+		t.Fatalf("Parse(list) error = %v", err)
+	}
+	if got := add.GetString("title"); got != "" {
+		t.Errorf("title after list = %q, want it unset", got)
+	}
+}
+
+func TestProvidedArgsAcrossParses(t *testing.T) {
+	p := NewParser("t", "")
+	add := p.NewCommand("add", "").Parser
+	add.String("t", "title", nil)
+	p.NewCommand("list", "")
+
+	tests := []struct {
+		args     []string
+		isSet    bool
+		provided []string
+	}{
+		{[]string{"add", "-t", "x"}, true, []string{"title"}},
+		{[]string{"list"}, false, []string{}},
+		{[]string{"add"}, false, []string{}},
+	}
+	for _, tt := range tests {
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := add.IsSet("title"); got != tt.isSet {
+			t.Errorf("after %q: IsSet(title) = %v, want %v", tt.args, got, tt.isSet)
+		}
+		if got := add.ProvidedArgs(); strings.Join(got, ",") != strings.Join(tt.provided, ",") {
+			t.Errorf("after %q: ProvidedArgs() = %q, want %q", tt.args, got, tt.provided)
+		}
+	}
+}
+
+func TestOnlyForBeforeCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"--scoped", "add"}, false},
+		{[]string{"add", "--scoped"}, false},
+		{[]string{"--scoped", "list"}, true},
+		{[]string{"list", "--scoped"}, true},
+		{[]string{"list"}, false},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.Bool("", "scoped", nil).OnlyFor("add")
+		p.NewCommand("add", "")
+		p.NewCommand("list", "")
+		_, err := p.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+		}
+		var perr *ParseError
+		if err != nil && (!errors.As(err, &perr) || perr.Kind != KindNotAllowed) {
+			t.Errorf("Parse(%q) error = %v, want KindNotAllowed", tt.args, err)
+		}
+	}
+}
+
+func TestOnlyForChained(t *testing.T) {
+	p := NewParser("t", "").AllowCommandChaining(true)
+	p.Bool("", "scoped", nil).OnlyFor("add")
+	p.NewCommand("add", "")
+	p.NewCommand("list", "")
+	if _, err := p.Parse([]string{"--scoped", "add", "list"}); err == nil {
+		t.Error("Parse succeeded with --scoped shared by a chained list")
+	}
+}
+
+func TestRemainder(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		verbose bool
+		rest    interface{}
+	}{
+		{"after first positional", []string{"run", "-v", "x", "--y"}, false, []string{"run", "-v", "x", "--y"}},
+		{"flags before", []string{"-v", "run", "x"}, true, []string{"run", "x"}},
+		{"separator", []string{"--", "-v"}, false, []string{"-v"}},
+		{"dash", []string{"-"}, false, []string{"-"}},
+		{"negative number", []string{"-5", "x"}, false, []string{"-5", "x"}},
+		{"empty", []string{}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("v", "verbose", nil)
+			p.Positional("rest", nil).Remainder()
+			result, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if result["verbose"] != tt.verbose {
+				t.Errorf("verbose = %v, want %v", result["verbose"], tt.verbose)
+			}
+			if !reflect.DeepEqual(result["rest"], tt.rest) {
+				t.Errorf("rest = %#v, want %#v", result["rest"], tt.rest)
+			}
+		})
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]interface{}
+	}{
+		{"cluster", []string{"-vcc"}, map[string]interface{}{"verbose": true, "count": 2}},
+		{"attached short value", []string{"-oout.txt"}, map[string]interface{}{"output": "out.txt"}},
+		{"short equals", []string{"-o=out.txt"}, map[string]interface{}{"output": "out.txt"}},
+		{"cluster ending in value", []string{"-vcoout"}, map[string]interface{}{"verbose": true, "count": 1, "output": "out"}},
+		{"long equals", []string{"--output=a=b"}, map[string]interface{}{"output": "a=b"}},
+		{"negative value", []string{"-n", "-5"}, map[string]interface{}{"num": -5}},
+		{"lone dash", []string{"-"}, map[string]interface{}{"files": []string{"-"}}},
+		{"multibyte attached value", []string{"-oé"}, map[string]interface{}{"output": "é"}},
+		{"greedy value", []string{"--words", "a", "b", "-v"}, map[string]interface{}{"words": "a b", "verbose": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newFuzzParser().Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			for name, want := range tt.want {
+				if !reflect.DeepEqual(result[name], want) {
+					t.Errorf("%s = %#v, want %#v", name, result[name], want)
+				}
+			}
+		})
+	}
+}
+
+// newFuzzParser defines a parser touching each tokenizer path: short
+// clusters, attached and '=' values, counters, negative numbers, greedy and
+// variadic values, the remainder and subcommands
+func newFuzzParser() *Parser {
+	p := NewParser("t", "").SetExitOnHelp(false).SetOutput(io.Discard).SetErrorOutput(io.Discard)
+	p.AddHelp()
+	p.Bool("v", "verbose", nil)
+	p.Counter("c", "count", nil)
+	p.String("o", "output", nil)
+	p.Int("n", "num", nil)
+	p.Float("", "ratio", nil)
+	p.List("", "tags", nil)
+	p.Bool("", "color", nil).Default(true)
+	p.String("", "words", nil).Greedy()
+	p.Positional("files", nil).Variadic()
+	add := p.NewCommand("add", "").Parser
+	add.String("t", "title", nil)
+	add.Positional("rest", nil).Remainder()
+	return p
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"-vc -o=out.txt -n -5 a b",
+		"-vcco out --ratio=.5 -- -x",
+		"--no-color --tags a,b --words one two -v",
+		"-n=3 -o- - --",
+		"add -t x -- -y z",
+		"--verb --out=é -é",
+		"-h",
+		"--=x -= ---",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		args := strings.Split(input, " ")
+		if input == "" {
+			args = []string{}
+		}
+		p := newFuzzParser()
+		want, err := p.Parse(args)
+		if err != nil {
+			if err.Error() == "" {
+				t.Errorf("Parse(%q) returned an error without a message", args)
+			}
+			return
+		}
+
+		// The reconstructed command line parses to the same values, except
+		// --help which it leaves out
+		line := p.ReconstructCommandLine()
+		got, err := newFuzzParser().Parse(shellSplit(line)[1:])
+		if err != nil {
+			t.Fatalf("Parse(%q) succeeded but its reconstruction %q fails: %v", args, line, err)
+		}
+		delete(want, "help")
+		delete(got, "help")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse(%q) = %v, but its reconstruction %q gives %v", args, want, line, got)
+		}
+	})
+}
+
+func TestHelpOnEmpty(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(p *Parser)
+		args   []string
+		want   error
+	}{
+		{"required flag", func(p *Parser) { p.String("", "name", nil).Required() }, []string{}, ErrHelpRequested},
+		{"required positional", func(p *Parser) { p.Positional("file", nil).Required() }, []string{}, ErrHelpRequested},
+		{"subcommands", func(p *Parser) { p.NewCommand("add", "") }, []string{}, ErrHelpRequested},
+		{"nothing required", func(p *Parser) { p.String("", "name", nil) }, []string{}, nil},
+		{"arguments given", func(p *Parser) { p.String("", "name", nil).Required() }, []string{"--name", "x"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").HelpOnEmpty(true)
+			tt.define(p)
+			if _, err := p.Parse(tt.args); !errors.Is(err, tt.want) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.args, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultFrom(t *testing.T) {
+	newParser := func() *Parser {
+		p := NewParser("t", "")
+		p.String("", "name", nil).Default("app")
+		p.String("", "log", nil).DefaultFrom(func(values map[string]interface{}) interface{} {
+			return values["dir"].(string) + "/" + values["name"].(string) + ".log"
+		}, "dir", "name")
+		p.String("", "dir", nil).DefaultFrom(func(values map[string]interface{}) interface{} {
+			return "/var/" + values["name"].(string)
+		}, "name")
+		return p
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "/var/app/app.log"},
+		{[]string{"--name", "web"}, "/var/web/web.log"},
+		{[]string{"--dir", "/tmp"}, "/tmp/app.log"},
+		{[]string{"--log", "x.log"}, "x.log"},
+	}
+	for _, tt := range tests {
+		p := newParser()
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := p.GetString("log"); got != tt.want {
+			t.Errorf("Parse(%q): log = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultFromCycle(t *testing.T) {
+	p := NewParser("t", "")
+	constant := func(map[string]interface{}) interface{} { return "x" }
+	p.String("", "a", nil).DefaultFrom(constant, "b")
+	p.String("", "b", nil).DefaultFrom(constant, "a")
+	_, err := p.Parse([]string{})
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Kind != KindInvalidDefinition {
+		t.Errorf("Parse error = %v, want a KindInvalidDefinition cycle error", err)
+	}
+}
+
+func TestGetRaw(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		arg  string
+		want []string
+	}{
+		{"separate value", []string{"--size", "0x10"}, "size", []string{"0x10"}},
+		{"attached value", []string{"--size=010"}, "size", []string{"010"}},
+		{"repeated", []string{"-t", "a", "-t", "b"}, "tag", []string{"a", "b"}},
+		{"positional", []string{"f.txt"}, "file", []string{"f.txt"}},
+		{"default", []string{}, "size", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("", "size", nil).Default("1")
+			p.String("t", "tag", nil).Append()
+			p.Positional("file", nil)
+			if _, err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.GetRaw(tt.arg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRaw(%q) = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistentFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+		debug   bool
+	}{
+		{[]string{"--debug", "add"}, false, true},
+		{[]string{"add", "--debug"}, false, true},
+		{[]string{"add", "--local"}, true, false},
+		{[]string{"--local", "add"}, false, false},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.Bool("", "debug", nil).Persistent()
+		p.Bool("", "local", nil)
+		p.NewCommand("add", "")
+		_, err := p.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+		}
+		if err == nil && p.GetBool("debug") != tt.debug {
+			t.Errorf("Parse(%q): debug = %v, want %v", tt.args, p.GetBool("debug"), tt.debug)
+		}
+	}
+}
+
+func TestEscapedDashValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		arg  string
+		want string
+	}{
+		{"positional", []string{`\--help`}, "query", "--help"},
+		{"flag value", []string{"--pattern", `\-x`}, "pattern", "-x"},
+		{"attached value", []string{`--pattern=\-x`}, "pattern", `\-x`},
+		{"after separator", []string{"--", `\-x`}, "query", `\-x`},
+		{"plain backslash", []string{`\n`}, "query", `\n`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.AddHelp()
+			p.String("", "pattern", nil)
+			p.Positional("query", nil)
+			if _, err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.GetString(tt.arg); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositionalOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		define  func(p *Parser)
+		wantErr bool
+	}{
+		{"required first", func(p *Parser) {
+			p.Positional("src", nil).Required()
+			p.Positional("dst", nil)
+		}, false},
+		{"required after optional", func(p *Parser) {
+			p.Positional("src", nil)
+			p.Positional("dst", nil).Required()
+		}, true},
+		{"remainder last", func(p *Parser) {
+			p.Positional("src", nil).Required()
+			p.Positional("rest", nil).Remainder()
+		}, false},
+		{"required after one or more", func(p *Parser) {
+			p.Positional("src", nil).Nargs("+")
+			p.Positional("dst", nil).Required()
+		}, true},
+		{"required after any number", func(p *Parser) {
+			p.Positional("src", nil).Nargs("*")
+			p.Positional("dst", nil).Required()
+		}, true},
+		{"counted after one or more", func(p *Parser) {
+			p.Positional("src", nil).Nargs("+")
+			p.Positional("dst", nil).Count(2)
+		}, true},
+		{"optional after one or more", func(p *Parser) {
+			p.Positional("src", nil).Nargs("+")
+			p.Positional("dst", nil)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			tt.define(p)
+			_, err := p.Parse([]string{"a", "b"})
+			var perr *ParseError
+			if tt.wantErr != (errors.As(err, &perr) && perr.Kind == KindInvalidDefinition) {
+				t.Errorf("Parse error = %v, want a definition error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNargs(t *testing.T) {
+	tests := []struct {
+		name    string
+		nargs   string
+		args    []string
+		want    interface{}
+		wantErr string
+	}{
+		{"optional given", "?", []string{"a"}, "a", ""},
+		{"optional omitted", "?", []string{}, nil, ""},
+		{"any number", "*", []string{"a", "b"}, []string{"a", "b"}, ""},
+		{"any number omitted", "*", []string{}, nil, ""},
+		{"one or more", "+", []string{"a", "b"}, []string{"a", "b"}, ""},
+		{"one or more omitted", "+", []string{}, nil, "required positional argument missing: files"},
+		{"one or more after separator", "+", []string{"--", "-a", "-b"}, []string{"-a", "-b"}, ""},
+		{"exact count", "2", []string{"a", "b"}, []string{"a", "b"}, ""},
+		{"invalid", "many", []string{"a"}, nil, `invalid nargs "many" for files (use ?, *, + or a number)`},
+		{"zero", "0", []string{"a"}, nil, `invalid nargs "0" for files (use ?, *, + or a number)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Positional("files", nil).Nargs(tt.nargs)
+			result, err := p.Parse(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := result["files"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGreedy(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		words string
+		file  string
+	}{
+		{"up to next flag", []string{"--words", "a", "b", "-v", "f"}, "a b", "f"},
+		{"to the end", []string{"--words", "a", "b"}, "a b", ""},
+		{"attached value", []string{"--words=a", "f"}, "a", "f"},
+		{"escaped dash", []string{"--words", "a", `\-b`, "-v"}, "a -b", ""},
+		{"short flag", []string{"-w", "a", "b"}, "a b", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("w", "words", nil).Greedy()
+			p.Bool("v", "verbose", nil)
+			p.Positional("file", nil)
+			if _, err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.GetString("words"); got != tt.words {
+				t.Errorf("words = %q, want %q", got, tt.words)
+			}
+			if got := p.GetString("file"); got != tt.file {
+				t.Errorf("file = %q, want %q", got, tt.file)
+			}
+		})
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{}, 0},
+		{[]string{"-v"}, 1},
+		{[]string{"-vvv"}, 3},
+		{[]string{"-v", "--verbose", "-q"}, 1},
+		{[]string{"-qq"}, -2},
+		{[]string{"-vqv"}, 1},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "").AddVerbosityFlags()
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := p.Verbosity(); got != tt.want {
+			t.Errorf("Parse(%q): Verbosity() = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPositionalDefaults(t *testing.T) {
+	p := NewParser("t", "")
+	p.Positional("src", nil).Required()
+	p.Positional("dst", nil).Default("out")
+	p.Positional("mode", nil).Default("copy")
+
+	tests := []struct {
+		args []string
+		dst  string
+		mode string
+	}{
+		{[]string{"a", "b", "move"}, "b", "move"},
+		{[]string{"a", "b"}, "b", "copy"},
+		{[]string{"a"}, "out", "copy"},
+	}
+	for _, tt := range tests {
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := p.GetString("dst"); got != tt.dst {
+			t.Errorf("Parse(%q): dst = %q, want %q", tt.args, got, tt.dst)
+		}
+		if got := p.GetString("mode"); got != tt.mode {
+			t.Errorf("Parse(%q): mode = %q, want %q", tt.args, got, tt.mode)
+		}
+	}
+}
+
+func TestValidators(t *testing.T) {
+	errSame := errors.New("source and destination are the same")
+	newParser := func() *Parser {
+		p := NewParser("t", "")
+		p.Bool("", "force", nil)
+		p.Positional("src", nil).Required()
+		p.Positional("dst", nil).Required()
+		p.AddValidator(func(values map[string]interface{}) error {
+			if values["src"] == values["dst"] && values["force"] != true {
+				return errSame
+			}
+			return nil
+		})
+		return p
+	}
+	tests := []struct {
+		args []string
+		want error
+	}{
+		{[]string{"a", "b"}, nil},
+		{[]string{"a", "a"}, errSame},
+		{[]string{"--force", "a", "a"}, nil},
+	}
+	for _, tt := range tests {
+		_, err := newParser().Parse(tt.args)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.args, err, tt.want)
+		}
+		var perr *ParseError
+		if err != nil && (!errors.As(err, &perr) || perr.Kind != KindValidation) {
+			t.Errorf("Parse(%q) error = %v, want KindValidation", tt.args, err)
+		}
+	}
+}
+
+func TestAddSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		args    []string
+		wantErr bool
+	}{
+		{"v|verbose", []string{"-v"}, false},
+		{"v|verbose", []string{"--verbose"}, false},
+		{"verbose", []string{"--verbose"}, false},
+		{"é|accent", []string{"-é"}, false},
+		{"vv|verbose", []string{"--verbose"}, true},
+		{"|verbose", []string{"--verbose"}, true},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.Add(tt.spec, &Argument{ArgType: Bool})
+		_, err := p.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Add(%q) then Parse(%q) error = %v, want error %v", tt.spec, tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEqualsInValues(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--define=key=value"}, "key=value"},
+		{[]string{"--define", "key=value"}, "key=value"},
+		{[]string{"-D=key=value"}, "key=value"},
+		{[]string{"-Dkey=value"}, "key=value"},
+		{[]string{"--define=="}, "="},
+		{[]string{"--define="}, ""},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.String("D", "define", nil)
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := p.GetString("define"); got != tt.want {
+			t.Errorf("Parse(%q): define = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTerminatingFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"terminator skips required", []string{"--dump-config"}, false},
+		{"terminator skips groups", []string{"--dump-config", "--json", "--text"}, false},
+		{"without terminator", []string{}, true},
+		{"invalid value still fails", []string{"--dump-config", "--level", "x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("", "dump-config", nil).Terminates()
+			p.String("", "name", nil).Required()
+			p.Int("", "level", nil)
+			p.NewExactlyOneGroup().Add(p.Bool("", "json", nil), p.Bool("", "text", nil))
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequiredIgnoresDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		wantErr bool
+	}{
+		{"default only", []string{}, "", true},
+		{"given", []string{"--region", "eu"}, "", false},
+		{"from environment", []string{}, "us", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("TEST_REGION", tt.env)
+			}
+			p := NewParser("t", "")
+			p.String("", "region", nil).Default("eu").Required().Env("TEST_REGION")
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequireEquals(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"--output=a"}, false},
+		{[]string{"--output", "a"}, true},
+		{[]string{"-o", "a"}, false},
+		{[]string{"--verbose"}, false},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "").RequireEquals(true)
+		p.String("o", "output", nil)
+		p.Bool("", "verbose", nil)
+		_, err := p.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSetMaxArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"a", "b"}, false},
+		{[]string{"a", "b", "c"}, true},
+		{[]string{"add", "a", "b"}, true},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "").SetMaxArgs(2)
+		p.Positional("files", nil).Variadic()
+		_, err := p.Parse(tt.args)
+		var perr *ParseError
+		if got := errors.As(err, &perr) && perr.Kind == KindTooManyArguments; got != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want KindTooManyArguments %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConditionalDefaults(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{}, 8080},
+		{[]string{"--tls"}, 8443},
+		{[]string{"--tls", "--port", "9000"}, 9000},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.Bool("", "tls", nil)
+		p.Int("", "port", nil).Default(8080).DefaultFrom(func(values map[string]interface{}) interface{} {
+			if values["tls"] == true {
+				return 8443
+			}
+			return nil
+		}, "tls")
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := p.GetInt("port"); got != tt.want {
+			t.Errorf("Parse(%q): port = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestProvidedArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{}, []string{}},
+		{[]string{"f", "--b", "--a"}, []string{"a", "b", "file"}},
+		{[]string{"--b", "sub", "--c"}, []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.Bool("", "a", nil)
+		p.Bool("", "b", nil)
+		p.String("", "level", nil).Default("1")
+		p.Positional("file", nil)
+		p.NewCommand("sub", "").Parser.Bool("", "c", nil)
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := p.ProvidedArgs(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q): ProvidedArgs() = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestVariadicPositionals(t *testing.T) {
+	positive := func(value interface{}) error {
+		if value.(int) <= 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		want    interface{}
+		wantErr bool
+	}{
+		{"typed slice", []string{"x", "1", "2", "3"}, []int{1, 2, 3}, false},
+		{"none", []string{"x"}, nil, false},
+		{"element conversion", []string{"x", "1", "two"}, nil, true},
+		{"element validation", []string{"x", "1", "-2"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Positional("name", nil).Required()
+			p.Positional("ids", &Argument{ArgType: Int}).Variadic().Validate(positive)
+			result, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(result["ids"], tt.want) {
+				t.Errorf("ids = %#v, want %#v", result["ids"], tt.want)
+			}
+		})
+	}
+}
+
+func TestNegativeAttachedValues(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-o=-5"}, -5},
+		{[]string{"--offset=-5"}, -5},
+		{[]string{"-o-5"}, -5},
+		{[]string{"-o", "-5"}, -5},
+		{[]string{"-o=5"}, 5},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.Int("o", "offset", nil)
+		p.Bool("5", "five", nil)
+		if _, err := p.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if got := p.GetInt("offset"); got != tt.want {
+			t.Errorf("Parse(%q): offset = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		verbose bool
+		files   interface{}
+	}{
+		{"dashed positionals", []string{"--", "-x", "--y", "file"}, false, []string{"-x", "--y", "file"}},
+		{"flags before", []string{"-v", "--", "-v"}, true, []string{"-v"}},
+		{"second separator", []string{"--", "--"}, false, []string{"--"}},
+		{"nothing after", []string{"--"}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("v", "verbose", nil)
+			p.Positional("files", nil).Variadic()
+			result, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if result["verbose"] != tt.verbose {
+				t.Errorf("verbose = %v, want %v", result["verbose"], tt.verbose)
+			}
+			if !reflect.DeepEqual(result["files"], tt.files) {
+				t.Errorf("files = %#v, want %#v", result["files"], tt.files)
+			}
+		})
+	}
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"int value", []string{"--offset", "-5"}, map[string]interface{}{"offset": -5}, false},
+		{"float value", []string{"--rate", "-1.5"}, map[string]interface{}{"rate": -1.5}, false},
+		{"positional", []string{"-42"}, map[string]interface{}{"target": -42}, false},
+		{"positional after flag", []string{"--offset", "1", "-42"}, map[string]interface{}{"offset": 1, "target": -42}, false},
+		{"not a number", []string{"--offset", "-x"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Int("", "offset", nil)
+			p.Float("", "rate", nil)
+			p.Positional("target", &Argument{ArgType: Int})
+			result, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			for name, want := range tt.want {
+				if !reflect.DeepEqual(result[name], want) {
+					t.Errorf("%s = %#v, want %#v", name, result[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeprecatedInFavorOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		want        string
+		wantWarning bool
+	}{
+		{"deprecated flag", []string{"--dir", "src"}, "src", true},
+		{"replacement", []string{"--directory", "src"}, "src", false},
+		{"neither", []string{}, ".", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings strings.Builder
+			p := NewParser("t", "").SetErrorOutput(&warnings)
+			p.String("", "dir", nil).DeprecatedInFavorOf("directory")
+			p.String("", "directory", nil).Default(".")
+			result, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if result["directory"] != tt.want {
+				t.Errorf("directory = %v, want %q", result["directory"], tt.want)
+			}
+			warned := strings.Contains(warnings.String(), "--dir is deprecated; use --directory instead")
+			if warned != tt.wantWarning {
+				t.Errorf("warning printed = %v, want %v: %q", warned, tt.wantWarning, warnings.String())
+			}
+		})
+	}
+}
+
+func TestEnvFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    interface{}
+		wantErr bool
+	}{
+		{"flag wins", []string{"--port", "1"}, map[string]string{"TEST_PORT": "2"}, 1, false},
+		{"environment", []string{}, map[string]string{"TEST_PORT": "2"}, 2, false},
+		{"default", []string{}, nil, 8080, false},
+		{"invalid environment value", []string{}, map[string]string{"TEST_PORT": "x"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			p := NewParser("t", "")
+			p.Int("", "port", nil).Default(8080).Env("TEST_PORT")
+			result, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && result["port"] != tt.want {
+				t.Errorf("port = %v, want %v", result["port"], tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandConflict(t *testing.T) {
+	tests := []struct {
+		name        string
+		choices     []string
+		args        []string
+		wantCommand []string
+		wantTarget  interface{}
+		wantDefErr  bool
+	}{
+		{"command wins", nil, []string{"build"}, []string{"build"}, nil, false},
+		{"other token is positional", nil, []string{"app"}, []string{}, "app", false},
+		{"separator makes it positional", nil, []string{"--", "build"}, []string{}, "build", false},
+		{"choices without the command", []string{"app", "lib"}, []string{"lib"}, []string{}, "lib", false},
+		{"choices include the command", []string{"app", "build"}, []string{"app"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			target := p.Positional("target", nil)
+			if tt.choices != nil {
+				target.Choices(tt.choices)
+			}
+			p.NewCommand("build", "")
+			result, err := p.Parse(tt.args)
+			var perr *ParseError
+			if tt.wantDefErr {
+				if !errors.As(err, &perr) || perr.Kind != KindInvalidDefinition {
+					t.Errorf("Parse(%q) error = %v, want a definition error", tt.args, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.Commands(); !reflect.DeepEqual(got, tt.wantCommand) {
+				t.Errorf("Commands() = %q, want %q", got, tt.wantCommand)
+			}
+			if tt.wantTarget != nil && result["target"] != tt.wantTarget {
+				t.Errorf("target = %v, want %v", result["target"], tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestTolerateUnknown(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantName     string
+		wantFiles    interface{}
+		wantWarnings []string
+	}{
+		{"unknown with value", []string{"--color", "red", "--name", "x"}, "x", nil, []string{"--color"}},
+		{"unknown with attached value", []string{"--color=red", "f"}, "", []string{"f"}, []string{"--color"}},
+		{"unknown switch", []string{"--fast", "--name", "x"}, "x", nil, []string{"--fast"}},
+		{"several", []string{"--a", "--b", "1", "--name", "x"}, "x", nil, []string{"--a", "--b"}},
+		{"known only", []string{"--name", "x", "f"}, "x", []string{"f"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings strings.Builder
+			p := NewParser("t", "").SetErrorOutput(&warnings).TolerateUnknown(true)
+			p.String("", "name", nil)
+			p.Positional("files", nil).Variadic()
+			result, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if name, _ := result["name"].(string); name != tt.wantName {
+				t.Errorf("name = %q, want %q", name, tt.wantName)
+			}
+			if !reflect.DeepEqual(result["files"], tt.wantFiles) {
+				t.Errorf("files = %#v, want %#v", result["files"], tt.wantFiles)
+			}
+			var want strings.Builder
+			for _, flag := range tt.wantWarnings {
+				want.WriteString("Warning: ignoring unknown argument: " + flag + "\n")
+			}
+			if warnings.String() != want.String() {
+				t.Errorf("warnings = %q, want %q", warnings.String(), want.String())
+			}
+		})
+	}
+}
+
+func TestPreParseHooks(t *testing.T) {
+	errHook := errors.New("hook failed")
+	defaultCommand := func(args []string) ([]string, error) {
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return append([]string{"status"}, args...), nil
+		}
+		return args, nil
+	}
+	alias := func(args []string) ([]string, error) {
+		if len(args) > 0 && args[0] == "st" {
+			return append([]string{"status"}, args[1:]...), nil
+		}
+		return args, nil
+	}
+	tests := []struct {
+		name    string
+		hooks   []func(args []string) ([]string, error)
+		args    []string
+		want    []string
+		wantErr error
+	}{
+		{"no hooks", nil, []string{"commit"}, []string{"commit"}, nil},
+		{"default command injected", []func([]string) ([]string, error){defaultCommand}, []string{}, []string{"status"}, nil},
+		{"default command before flags", []func([]string) ([]string, error){defaultCommand}, []string{"-v"}, []string{"status"}, nil},
+		{"command given", []func([]string) ([]string, error){defaultCommand}, []string{"commit"}, []string{"commit"}, nil},
+		{"alias rewritten", []func([]string) ([]string, error){alias}, []string{"st"}, []string{"status"}, nil},
+		{"hooks chain", []func([]string) ([]string, error){alias, defaultCommand}, []string{"st", "-v"}, []string{"status"}, nil},
+		{"hook error", []func([]string) ([]string, error){func([]string) ([]string, error) { return nil, errHook }}, []string{"commit"}, nil, errHook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("v", "verbose", nil).Persistent()
+			p.NewCommand("status", "")
+			p.NewCommand("commit", "")
+			for _, hook := range tt.hooks {
+				p.AddPreParseHook(hook)
+			}
+			_, err := p.Parse(tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(p.Commands(), tt.want) {
+				t.Errorf("Commands() = %q, want %q", p.Commands(), tt.want)
+			}
+		})
+	}
+}
+
+func TestArgumentValidate(t *testing.T) {
+	errOdd := errors.New("must be even")
+	errLarge := errors.New("must be below 100")
+	even := func(value interface{}) error {
+		if value.(int)%2 != 0 {
+			return errOdd
+		}
+		return nil
+	}
+	small := func(value interface{}) error {
+		if value.(int) >= 100 {
+			return errLarge
+		}
+		return nil
+	}
+	tests := []struct {
+		name string
+		args []string
+		want error
+	}{
+		{"valid", []string{"--n", "4"}, nil},
+		{"first fails", []string{"--n", "3"}, errOdd},
+		{"second fails", []string{"--n", "200"}, errLarge},
+		{"both fail reports the first", []string{"--n", "201"}, errOdd},
+		{"positionals each checked", []string{"2", "5", "7"}, errOdd},
+		{"not given", []string{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Int("", "n", nil).Validate(even).Validate(small)
+			p.Positional("ids", &Argument{ArgType: Int}).Variadic().Validate(even)
+			_, err := p.Parse(tt.args)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.args, err, tt.want)
+			}
+			var cause *InvalidValueError
+			if err != nil && (!errors.As(err, &cause) || !strings.Contains(err.Error(), cause.Name)) {
+				t.Errorf("Parse(%q) error = %v, want an invalid value error naming the argument", tt.args, err)
+			}
+		})
+	}
+}
+
+func TestDefaultCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		want    []string
+		wantAll bool
+		wantErr bool
+	}{
+		{"no tokens", "list", []string{}, []string{"list"}, false, false},
+		{"command flag only", "list", []string{"--all"}, []string{"list"}, true, false},
+		{"positional only", "list", []string{"x"}, []string{"list"}, false, false},
+		{"explicit command", "list", []string{"add"}, []string{"add"}, false, false},
+		{"own flag first", "list", []string{"-v", "add"}, []string{"add"}, false, false},
+		{"unknown default", "bogus", []string{}, nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetDefaultCommand(tt.command)
+			p.Bool("v", "verbose", nil)
+			list := p.NewCommand("list", "").Parser
+			list.Bool("a", "all", nil)
+			list.Positional("filter", nil)
+			p.NewCommand("add", "")
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := p.Commands(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Commands() = %q, want %q", got, tt.want)
+			}
+			if got := list.GetBool("all"); got != tt.wantAll {
+				t.Errorf("all = %v, want %v", got, tt.wantAll)
+			}
+		})
+	}
+}
+
+func TestAbbreviations(t *testing.T) {
+	tests := []struct {
+		name     string
+		disable  bool
+		args     []string
+		want     map[string]interface{}
+		wantKind ErrorKind
+		wantErr  string
+	}{
+		{"unique prefix", false, []string{"--verb"}, map[string]interface{}{"verbose": true}, 0, ""},
+		{"prefix with value", false, []string{"--out=x"}, map[string]interface{}{"output": "x"}, 0, ""},
+		{"exact name wins", false, []string{"--ver"}, map[string]interface{}{"ver": true, "verbose": false}, 0, ""},
+		{"ambiguous", false, []string{"--ve"}, nil, KindAmbiguousOption, "ambiguous option --ve (could match --verbose, --ver, --version-file)"},
+		{"disabled", true, []string{"--verb"}, nil, KindUnknownArgument, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").AllowAbbrev(!tt.disable)
+			p.Bool("", "verbose", nil)
+			p.Bool("", "ver", nil)
+			p.String("", "version-file", nil)
+			p.String("", "output", nil)
+			result, err := p.Parse(tt.args)
+			if tt.want == nil {
+				var perr *ParseError
+				if !errors.As(err, &perr) || perr.Kind != tt.wantKind || tt.wantErr != "" && err.Error() != tt.wantErr {
+					t.Errorf("Parse(%q) error = %v, want kind %d %q", tt.args, err, tt.wantKind, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			for name, want := range tt.want {
+				if result[name] != want {
+					t.Errorf("%s = %v, want %v", name, result[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandPrecedence(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantCommand []string
+		want        map[string]interface{}
+	}{
+		{"command name first", []string{"list"}, []string{"list"}, nil},
+		{"command after flags", []string{"-v", "list"}, []string{"list"}, map[string]interface{}{"verbose": true}},
+		{"command with its positional", []string{"list", "x"}, []string{"list"}, map[string]interface{}{"what": "x"}},
+		{"command name after a positional", []string{"x", "list"}, []string{}, map[string]interface{}{"target": "x", "more": "list"}},
+		{"command name after separator", []string{"--", "list"}, []string{}, map[string]interface{}{"target": "list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("v", "verbose", nil)
+			p.Positional("target", nil)
+			p.Positional("more", nil)
+			list := p.NewCommand("list", "").Parser
+			list.Positional("what", nil)
+			result, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.Commands(); !reflect.DeepEqual(got, tt.wantCommand) {
+				t.Errorf("Commands() = %q, want %q", got, tt.wantCommand)
+			}
+			for name, want := range tt.want {
+				if result[name] != want {
+					t.Errorf("%s = %v, want %v", name, result[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFixedCountPositionals(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{"exact", []string{"1", "2", "3"}, map[string]interface{}{"coords": []int{1, 2, 3}}, ""},
+		{"with variadic rest", []string{"1", "2", "3", "a", "b"}, map[string]interface{}{"coords": []int{1, 2, 3}, "labels": []string{"a", "b"}}, ""},
+		{"flags between", []string{"1", "-v", "2", "3"}, map[string]interface{}{"coords": []int{1, 2, 3}, "verbose": true}, ""},
+		{"too few", []string{"1", "2"}, nil, "positional coords requires 3 values, got 2"},
+		{"none", []string{}, nil, "positional coords requires 3 values, got 0"},
+		{"invalid element", []string{"1", "x", "3"}, nil, "coords"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("v", "verbose", nil)
+			p.Positional("coords", &Argument{ArgType: Int}).Count(3)
+			p.Positional("labels", nil).Variadic()
+			result, err := p.Parse(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			for name, want := range tt.want {
+				if !reflect.DeepEqual(result[name], want) {
+					t.Errorf("%s = %#v, want %#v", name, result[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPipe(t *testing.T) {
+	errNotEmail := errors.New("not an email address")
+	trim := func(value string) (string, error) { return strings.TrimSpace(value), nil }
+	lower := func(value string) (string, error) { return strings.ToLower(value), nil }
+	email := func(value string) (string, error) {
+		if !strings.Contains(value, "@") {
+			return "", errNotEmail
+		}
+		return value, nil
+	}
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{"all steps pass", "  Me@Example.COM ", "me@example.com", nil},
+		{"fails at the last step", " ME ", "", errNotEmail},
+		{"choice checked after the pipeline", " ADMIN@HOST ", "admin@host", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("", "email", nil).Pipe(trim, lower).Pipe(email).Choices([]string{"me@example.com", "admin@host"})
+			result, err := p.Parse([]string{"--email", tt.value})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.value, err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), "--email") {
+					t.Errorf("error = %q, want it to name --email", err)
+				}
+				return
+			}
+			if result["email"] != tt.want {
+				t.Errorf("email = %q, want %q", result["email"], tt.want)
+			}
+		})
+	}
+}
+
+func TestShortEquals(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		verbose bool
+	}{
+		{[]string{"-n=bob"}, "bob", false},
+		{[]string{"-nbob"}, "bob", false},
+		{[]string{"-n", "bob"}, "bob", false},
+		{[]string{"-n=a=b"}, "a=b", false},
+		{[]string{"-n=="}, "=", false},
+		{[]string{"-vn=bob"}, "bob", true},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.String("n", "name", nil)
+		p.Bool("v", "verbose", nil)
+		result, err := p.Parse(tt.args)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if result["name"] != tt.want || result["verbose"] != tt.verbose {
+			t.Errorf("Parse(%q): name, verbose = %q, %v, want %q, %v", tt.args, result["name"], result["verbose"], tt.want, tt.verbose)
+		}
+	}
+}
+
+func TestTreatEmptyAsUnset(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(p *Parser, arg *Argument)
+		args    []string
+		wantErr bool
+	}{
+		{"empty accepted by default", func(p *Parser, arg *Argument) {}, []string{"--name", ""}, false},
+		{"empty rejected per argument", func(p *Parser, arg *Argument) { arg.TreatEmptyAsUnset() }, []string{"--name", ""}, true},
+		{"empty rejected parser-wide", func(p *Parser, arg *Argument) { p.TreatEmptyAsUnset(true) }, []string{"--name="}, true},
+		{"value accepted", func(p *Parser, arg *Argument) { arg.TreatEmptyAsUnset() }, []string{"--name", "x"}, false},
+		{"not given", func(p *Parser, arg *Argument) {}, []string{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			tt.setup(p, p.String("", "name", nil).Required())
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			var perr *ParseError
+			if err != nil && (!errors.As(err, &perr) || perr.Kind != KindMissingRequired) {
+				t.Errorf("Parse(%q) error = %v, want KindMissingRequired", tt.args, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package argparse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Bind defines an argument for each exported field of the struct dst points
+// to and fills the fields after every successful Parse. The `arg` tag lists
+// the flag names and options, as in `arg:"-n,--name,required"`; without a
+// long name the field's kebab-cased name is used, and `arg:"-"` skips the
+// field. The `help` tag sets the description. A field tagged
+// `arg:"--name,command"` is a struct, or pointer to one, whose fields define
+// subcommand name. The `argparse` tag is accepted in place of `arg`.
+// The type follows the field: string, int, int64, uint64, float64, float32,
+// bool, []string, time.Time or time.Duration. A non-zero field value becomes the default.
+// A field whose name is already defined reuses that argument, so flags
+// defined with the builders before Bind keep their settings.
+func (p *Parser) Bind(dst interface{}) *Parser {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		p.setDefErr(p.errorf(KindInvalidDefinition, "", p.msgs().BindTarget, dst))
+		return p
+	}
+	if err := defineStruct(p, v.Elem()); err != nil {
+		p.setDefErr(err)
+		return p
+	}
+	p.bindings = append(p.bindings, v.Elem())
+	return p
+}
+
+// defineStruct defines the arguments and subcommands for the fields of v
+func defineStruct(p *Parser, v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, bad := parseFieldTag(field)
+		if bad != "" {
+			return p.errorf(KindInvalidDefinition, tag.name, p.msgs().BindTagOption, bad, field.Name)
+		}
+		if tag.skip {
+			continue
+		}
+		name := tag.name
+		fv := v.Field(i)
+
+		if tag.command {
+			if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+				fv = reflect.New(fv.Type().Elem()).Elem()
+			}
+			if fv.Kind() != reflect.Struct {
+				return p.errorf(KindInvalidDefinition, name, p.msgs().BindCommandField, field.Name)
+			}
+			sub, ok := p.subparsers[name]
+			if !ok {
+				sub = p.NewCommand(name, field.Tag.Get("help")).Parser
+			}
+			if err := defineStruct(sub, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if p.findOption(func(option *Argument) bool { return option.Name == name }) != nil {
+			continue
+		}
+		argType, ok := bindType(fv.Type())
+		if !ok {
+			return p.errorf(KindInvalidDefinition, name, p.msgs().BindFieldType, field.Name, fv.Type())
+		}
+		arg := &Argument{ArgType: argType, IsRequired: tag.required, Description: field.Tag.Get("help")}
+		switch {
+		case !fv.IsZero():
+			arg.DefaultVal = fv.Interface()
+		case argType == Bool:
+			arg.DefaultVal = false
+		case argType == List:
+			arg.DefaultVal = make([]string, 0)
+		}
+		p.Flag(tag.short, name, arg)
+	}
+	return nil
+}
+
+// bindType returns the argument type for a bound field of type t
+func bindType(t reflect.Type) (ArgumentType, bool) {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return DateTime, true
+	case reflect.TypeOf(time.Duration(0)):
+		return Duration, true
+	case reflect.TypeOf([]string(nil)):
+		return List, true
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return String, true
+	case reflect.Int:
+		return Int, true
+	case reflect.Int64:
+		return Int64, true
+	case reflect.Uint64:
+		return Uint, true
+	case reflect.Float64:
+		return Float, true
+	case reflect.Float32:
+		return Float32, true
+	case reflect.Bool:
+		return Bool, true
+	}
+	return 0, false
+}
+
+// setDefErr records the first definition error, reported by Parse
+func (p *Parser) setDefErr(err error) {
+	if p.defErr == nil {
+		p.defErr = err
+	}
+}
+
+// store keeps the result of a successful parse for the getters and copies it
+// into the structs registered with Bind
+func (p *Parser) store(result map[string]interface{}) (map[string]interface{}, error) {
+	p.result = result
+	for _, v := range p.bindings {
+		if err := bindStruct(p, result, v); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Build returns a function that parses a command line into a value of type T.
+// configure defines the arguments; each parsed value is stored in the field of
+// T tagged `arg:"--name"`, or whose kebab-cased name matches the argument.
+// Fields tagged `arg:"--name,command"` hold the values of a subcommand.
+func Build[T any](configure func(p *Parser)) func(args []string) (T, error) {
+	parser := NewParser(filepath.Base(os.Args[0]), "")
+	configure(parser)
+
+	return func(args []string) (T, error) {
+		var config T
+		result, err := parser.Parse(args)
+		if err != nil {
+			return config, err
+		}
+		if err := bindResult(parser, result, &config); err != nil {
+			return config, err
+		}
+		return config, nil
+	}
+}
+
+// bindResult copies parsed values into the struct dst points to
+func bindResult(p *Parser, result map[string]interface{}, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf(p.msgs().BindTarget, dst)
+	}
+	return bindStruct(p, result, v.Elem())
+}
+
+// bindStruct fills the fields of v. A field tagged `arg:"--name,command"`
+// is a struct, or pointer to one, filled only when subcommand name was
+// selected; a pointer stays nil otherwise.
+func bindStruct(p *Parser, result map[string]interface{}, v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, _ := parseFieldTag(field)
+		if tag.skip {
+			continue
+		}
+		name := tag.name
+		fv := v.Field(i)
+
+		if tag.command {
+			if p == nil || !containsString(p.selectedCommands(), name) {
+				continue
+			}
+			if fv.Kind() == reflect.Ptr {
+				if fv.Type().Elem().Kind() != reflect.Struct {
+					return fmt.Errorf(p.msgs().BindCommandField, field.Name)
+				}
+				fv.Set(reflect.New(fv.Type().Elem()))
+				fv = fv.Elem()
+			}
+			if fv.Kind() != reflect.Struct {
+				return fmt.Errorf(p.msgs().BindCommandField, field.Name)
+			}
+			values := result
+			if len(p.chain) > 0 {
+				values = p.subparsers[name].result
+			}
+			if err := bindStruct(p.subparsers[name], values, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		val, ok := result[name]
+		if !ok || val == nil {
+			continue
+		}
+
+		rv := reflect.ValueOf(val)
+		switch {
+		case rv.Type().AssignableTo(fv.Type()):
+			fv.Set(rv)
+		case isNumeric(rv.Kind()) && isNumeric(fv.Kind()):
+			fv.Set(rv.Convert(fv.Type()))
+		default:
+			return fmt.Errorf(p.msgs().BindStore, name, val, field.Name, fv.Type())
+		}
+	}
+	return nil
+}
+
+// isNumeric reports whether values of kind k are numbers
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldTag is the parsed `arg` tag of a bound struct field
+type fieldTag struct {
+	name     string
+	short    string
+	required bool
+	command  bool
+	skip     bool
+}
+
+// parseFieldTag reads the `arg` tag of a field, or its `argparse` alias: a
+// comma-separated list of -x, --name, required and command. A leading bare
+// name and short=x are also accepted. The name defaults to the field's
+// kebab-cased name. bad is the first option that could not be read.
+func parseFieldTag(field reflect.StructField) (tag fieldTag, bad string) {
+	text, ok := field.Tag.Lookup("arg")
+	if !ok {
+		text = field.Tag.Get("argparse")
+	}
+	if text == "-" {
+		return fieldTag{skip: true}, ""
+	}
+
+	for i, option := range strings.Split(text, ",") {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == "":
+		case option == "required":
+			tag.required = true
+		case option == "command":
+			tag.command = true
+		case strings.HasPrefix(option, "--") && len(option) > 2:
+			tag.name = option[2:]
+		case strings.HasPrefix(option, "-") && utf8.RuneCountInString(option) == 2:
+			tag.short = option[1:]
+		case strings.HasPrefix(option, "short=") && utf8.RuneCountInString(option) == len("short=")+1:
+			tag.short = option[len("short="):]
+		case i == 0 && !strings.HasPrefix(option, "-") && !strings.Contains(option, "="):
+			tag.name = option
+		default:
+			if bad == "" {
+				bad = option
+			}
+		}
+	}
+	if tag.name == "" {
+		tag.name = kebabCase(field.Name)
+	}
+	return tag, bad
+}
+
+// kebabCase converts a Go identifier like LogFile to log-file
+func kebabCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package argparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type bindOptions struct {
+	Name    string `arg:"-n,--name,required" help:"Your name"`
+	Verbose bool   `arg:"-v,--verbose"`
+	Tags    []string
+	Level   int `arg:"--lvl"`
+	Skipped int `arg:"-"`
+	Add     *struct {
+		Title string `arg:"-t"`
+	} `arg:"--add,command"`
+	List *struct {
+		All bool
+	} `argparse:"list,command"`
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    bindOptions
+		wantAdd string
+		wantErr bool
+	}{
+		{"flags", []string{"-n", "x", "-v", "--tags", "a,b", "--lvl", "2"}, bindOptions{Name: "x", Verbose: true, Tags: []string{"a", "b"}, Level: 2}, "", false},
+		{"default", []string{"-n", "x"}, bindOptions{Name: "x", Tags: []string{}, Level: 1}, "", false},
+		{"command", []string{"-n", "x", "add", "-t", "todo"}, bindOptions{Name: "x", Tags: []string{}, Level: 1}, "todo", false},
+		{"required", []string{"-v"}, bindOptions{}, "", true},
+		{"skipped field", []string{"-n", "x", "--skipped", "1"}, bindOptions{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			opts := bindOptions{Level: 1}
+			p.Bind(&opts)
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if (opts.Add != nil) != (tt.wantAdd != "") {
+				t.Fatalf("Add = %v, want it set %v", opts.Add, tt.wantAdd != "")
+			}
+			if opts.Add != nil && opts.Add.Title != tt.wantAdd {
+				t.Errorf("Add.Title = %q, want %q", opts.Add.Title, tt.wantAdd)
+			}
+			if opts.List != nil {
+				t.Errorf("List = %v, want nil", opts.List)
+			}
+			opts.Add = nil
+			if !reflect.DeepEqual(opts, tt.want) {
+				t.Errorf("Bind filled %+v, want %+v", opts, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindReusesDefinedFlag(t *testing.T) {
+	p := NewParser("t", "")
+	p.Int("", "level", nil).Min(1)
+	opts := struct{ Level int }{}
+	p.Bind(&opts)
+	if _, err := p.Parse([]string{"--level", "0"}); err == nil {
+		t.Error("Parse(--level 0) succeeded, want the builder's Min to apply")
+	}
+	if _, err := p.Parse([]string{"--level", "3"}); err != nil || opts.Level != 3 {
+		t.Errorf("Parse(--level 3) error = %v, Level = %d, want 3", err, opts.Level)
+	}
+}
+
+func TestBindArgparseAlias(t *testing.T) {
+	opts := struct {
+		Name  string `argparse:"name,short=n,required"`
+		Level int    `argparse:"lvl"`
+		Skip  int    `argparse:"-"`
+	}{}
+	p := NewParser("t", "")
+	p.Bind(&opts)
+	if _, err := p.Parse([]string{"-n", "x", "--lvl", "2"}); err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+	if opts.Name != "x" || opts.Level != 2 {
+		t.Errorf("Bind filled %+v, want Name x and Level 2", opts)
+	}
+	if _, err := p.Parse([]string{"--lvl", "2"}); err == nil {
+		t.Error("Parse succeeded without the required --name")
+	}
+}
+
+func TestBindInvalidTag(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  interface{}
+		want string
+	}{
+		{"long short name", &struct {
+			Name string `arg:"-nn,--name"`
+		}{}, `"-nn"`},
+		{"unknown option", &struct {
+			Name string `arg:"--name,optional"`
+		}{}, `"optional"`},
+		{"alias short option", &struct {
+			Name string `argparse:"name,short=nn"`
+		}{}, `"short=nn"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bind(tt.dst)
+			_, err := p.Parse([]string{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want the invalid tag option %s reported", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	type config struct {
+		Name    string
+		Verbose bool
+		Count   int `argparse:"num"`
+		LogFile string
+		Ignored string `argparse:"-"`
+	}
+	parse := Build[config](func(p *Parser) {
+		p.String("n", "name", nil).Required()
+		p.Bool("v", "verbose", nil)
+		p.Int("c", "num", nil).Default(1)
+		p.String("", "log-file", nil)
+		p.String("", "ignored", nil)
+	})
+
+	tests := []struct {
+		args    []string
+		want    config
+		wantErr bool
+	}{
+		{[]string{"-n", "x"}, config{Name: "x", Count: 1}, false},
+		{[]string{"-n", "x", "-v", "-c", "3", "--log-file", "l", "--ignored", "i"}, config{Name: "x", Verbose: true, Count: 3, LogFile: "l"}, false},
+		{[]string{"-v"}, config{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parse(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestBuildCommands(t *testing.T) {
+	type addConfig struct {
+		Title string
+	}
+	type removeConfig struct {
+		Force bool
+	}
+	type config struct {
+		Verbose bool
+		Add     addConfig     `arg:"--add,command"`
+		Remove  *removeConfig `arg:"--remove,command"`
+	}
+	parse := Build[config](func(p *Parser) {
+		p.Bool("v", "verbose", nil)
+		p.NewCommand("add", "").Parser.String("t", "title", nil)
+		p.NewCommand("remove", "").Parser.Bool("f", "force", nil)
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		want config
+	}{
+		{"no command", []string{"-v"}, config{Verbose: true}},
+		{"add", []string{"add", "-t", "x"}, config{Add: addConfig{Title: "x"}}},
+		{"remove", []string{"-v", "remove", "-f"}, config{Verbose: true, Remove: &removeConfig{Force: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.args)
+			if err != nil {
+				t.Fatalf("parse(%q) error = %v", tt.args, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package argparse
+
+import "strings"
+
+// AllowCommandChaining lets several subcommands follow each other in one
+// invocation, as in "tool build --fast test -v deploy". Each command takes
+// the tokens up to the next command name, so its own flags go after it and
+// before the next command; persistent flags are accepted in every segment.
+// RunContext calls the handlers in order. A command name can be passed as a
+// value by giving it as a flag's value or after "--".
+func (p *Parser) AllowCommandChaining(enable bool) *Parser {
+	p.chaining = enable
+	return p
+}
+
+// Commands returns the subcommands selected by the last parse, in the order
+// they were given: several with command chaining, otherwise at most one
+func (p *Parser) Commands() []string {
+	p.results()
+	if len(p.chain) > 0 {
+		return append([]string{}, p.chain...)
+	}
+	if p.subparser != "" {
+		return []string{p.subparser}
+	}
+	return []string{}
+}
+
+// parseChain parses args from index start as a sequence of subcommands, each
+// with its own segment of tokens. Every command's result also holds the
+// values given before the first command.
+func (p *Parser) parseChain(args []string, start, implicit int, result map[string]interface{}) (map[string]interface{}, error) {
+	if err := p.applyEnv(result); err != nil {
+		return nil, err
+	}
+	shared := make(map[string]interface{}, len(result))
+	for k, v := range result {
+		shared[k] = v
+	}
+
+	for i := start; i < len(args); {
+		name := args[i]
+		subparser := p.subparsers[name]
+		if err := p.checkOnlyFor(name); err != nil {
+			return nil, err
+		}
+		end := p.segmentEnd(subparser, args, i+1)
+		p.tracef("%q: chained subcommand %s", name, name)
+
+		subResult, err := subparser.Parse(args[i+1 : end])
+		if err != nil {
+			return nil, shiftPosition(err, i+1-implicit)
+		}
+		for k, v := range shared {
+			if _, ok := subResult[k]; !ok {
+				subResult[k] = v
+			}
+		}
+		for k, v := range subResult {
+			result[k] = v
+		}
+		for k, v := range subparser.raw {
+			p.raw[k] = v
+		}
+
+		// A command given twice runs once, with its last values
+		if !containsString(p.chain, name) {
+			p.chain = append(p.chain, name)
+		}
+		p.subparser = name
+		i = end
+	}
+
+	result["subcommand"] = p.subparser
+	result["commands"] = append([]string{}, p.chain...)
+	return p.finish(result)
+}
+
+// segmentEnd returns the index of the next command name after from, which
+// ends the segment of subparser, or len(args). A token that is the value of
+// the preceding flag, or follows "--", does not end the segment.
+func (p *Parser) segmentEnd(subparser *Parser, args []string, from int) int {
+	for j := from; j < len(args); j++ {
+		if args[j] == "--" {
+			return len(args)
+		}
+		if _, ok := p.subparsers[args[j]]; !ok {
+			continue
+		}
+		if prev := args[j-1]; j > from && strings.HasPrefix(prev, "-") && subparser.completionValueFlag(prev) != nil {
+			continue
+		}
+		return j
+	}
+	return len(args)
+}
+
+// inChain reports whether p is one of the commands its parent chained
+func (p *Parser) inChain() bool {
+	return p.parent != nil && p.parent.subparsers[p.name] == p && containsString(p.parent.chain, p.name)
+}
+
+// activeParsers returns p and the subcommands selected beneath it, depth first
+func (p *Parser) activeParsers() []*Parser {
+	parsers := []*Parser{p}
+	for _, name := range p.selectedCommands() {
+		parsers = append(parsers, p.subparsers[name].activeParsers()...)
+	}
+	return parsers
+}
+
+// selectedCommands returns the names of the subcommands the last parse of p
+// selected, without parsing
+func (p *Parser) selectedCommands() []string {
+	if len(p.chain) > 0 {
+		return p.chain
+	}
+	if p.subparser != "" {
+		return []string{p.subparser}
+	}
+	return nil
+}
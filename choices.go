@@ -0,0 +1,116 @@
+package argparse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrChoicesListed is returned by Parse when the choices listing flag is
+// given; ParseOrExit then prints the choices and exits
+var ErrChoicesListed = errors.New("choices listed")
+
+// ChoicesFrom sets a function returning the valid choices, for choices only
+// known at run time. It is called each time the choices are needed and takes
+// the place of ValidChoices.
+func (a *Argument) ChoicesFrom(choices func() []string) *Argument {
+	a.ChoicesFunc = choices
+	return a
+}
+
+// maxListedRange is the largest ChoiceRange enumerated for completion and
+// --list-choices; larger ranges are only checked and shown as {lo..hi}
+const maxListedRange = 1000
+
+// ChoiceRange makes the integers from lo to hi, inclusive, the valid
+// choices of an integer argument. Help shows them as {lo..hi}, and completion
+// and --list-choices enumerate ranges of up to 1000 values.
+func (a *Argument) ChoiceRange(lo, hi int) *Argument {
+	if lo > hi {
+		if a.parent != nil {
+			a.parent.setDefErr(a.parent.errorf(KindInvalidDefinition, a.Name, a.parent.msgs().InvalidChoiceRange, lo, hi, a.Name))
+		}
+		return a
+	}
+	a.choiceRange = []int{lo, hi}
+	return a
+}
+
+// choices returns the valid choices, from ChoicesFunc or ChoiceRange if set
+func (a *Argument) choices() []string {
+	if a.ChoicesFunc != nil {
+		return a.ChoicesFunc()
+	}
+	if a.choiceRange != nil {
+		lo, hi := a.choiceRange[0], a.choiceRange[1]
+		// hi-lo overflows to a negative number for the widest ranges
+		if span := hi - lo; span < 0 || span >= maxListedRange {
+			return nil
+		}
+		choices := make([]string, 0, hi-lo+1)
+		for n := lo; n <= hi; n++ {
+			choices = append(choices, strconv.Itoa(n))
+		}
+		return choices
+	}
+	return a.ValidChoices
+}
+
+// hasChoice reports whether value is one of the valid choices, testing a
+// ChoiceRange arithmetically instead of listing it
+func (a *Argument) hasChoice(value string) bool {
+	if a.ChoicesFunc == nil && a.choiceRange != nil {
+		n, err := strconv.Atoi(value)
+		return err == nil && n >= a.choiceRange[0] && n <= a.choiceRange[1]
+	}
+	return containsString(a.choices(), value)
+}
+
+// rangeLabel returns the lo..hi form of a ChoiceRange, used in error
+// messages; help braces it like other inline choices
+func (a *Argument) rangeLabel() string {
+	return fmt.Sprintf("%d..%d", a.choiceRange[0], a.choiceRange[1])
+}
+
+// AddChoicesListingFlag adds --list-choices NAME, which makes ParseOrExit
+// print the valid choices of the named argument, one per line, and exit
+// without checking required arguments
+func (p *Parser) AddChoicesListingFlag() *Argument {
+	p.choicesFlag = p.String("", "list-choices", &Argument{
+		Description: p.msgs().ListChoicesDescription,
+		Metavar:     "NAME",
+	}).Terminates()
+	return p.choicesFlag
+}
+
+// requestChoices records the argument named by the listing flag for
+// printChoices and returns ErrChoicesListed
+func (p *Parser) requestChoices(result map[string]interface{}) error {
+	name, _ := result[p.choicesFlag.Name].(string)
+	p.choicesFor = nil
+	for _, candidate := range append(p.options(), p.positional...) {
+		if candidate.Name == name {
+			p.choicesFor = candidate
+			break
+		}
+	}
+	if p.choicesFor == nil {
+		return p.errorf(KindInvalidValue, "--"+p.choicesFlag.Name, p.msgs().UnknownArgument, name)
+	}
+	p.root().requestedBy = p
+	return ErrChoicesListed
+}
+
+// printChoices prints the choices requested with the listing flag, one per line
+func (p *Parser) printChoices() {
+	if p.choicesFor == nil {
+		return
+	}
+	choices := p.choicesFor.choices()
+	if len(choices) == 0 && p.choicesFor.choiceRange != nil {
+		choices = []string{p.choicesFor.rangeLabel()}
+	}
+	for _, choice := range choices {
+		fmt.Fprintln(p.output(), choice)
+	}
+}
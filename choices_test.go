@@ -0,0 +1,152 @@
+package argparse
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestChoiceRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		lo, hi  int
+		args    []string
+		wantErr bool
+	}{
+		{"inside", 1, 5, []string{"--level", "3"}, false},
+		{"lower bound", 1, 5, []string{"--level", "1"}, false},
+		{"above", 1, 5, []string{"--level", "6"}, true},
+		{"not a number", 1, 5, []string{"--level", "x"}, true},
+		{"reversed", 10, 1, []string{}, true},
+		{"widest", math.MinInt, math.MaxInt, []string{"--level", "123456789"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Int("", "level", nil).ChoiceRange(tt.lo, tt.hi)
+			p.NewCommand("10", "")
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChoiceRangeHelp(t *testing.T) {
+	p := NewParser("t", "").SetHelpWidth(80)
+	p.Int("", "level", nil).ChoiceRange(1, 5)
+	p.Int("", "port", nil).ChoiceRange(1, 65535)
+	help := p.HelpString()
+	for _, want := range []string{"--level {1..5}", "--port {1..65535}"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help = %q, want it to contain %q", help, want)
+		}
+	}
+}
+
+func TestChoiceRangeDefinitionError(t *testing.T) {
+	p := NewParser("t", "")
+	p.Positional("level", nil).ChoiceRange(10, 1)
+	p.NewCommand("5", "")
+	p.HelpString()
+	if errs := p.Lint(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "10..1") {
+		t.Errorf("Lint() = %v, want the reversed range reported", errs)
+	}
+}
+
+func TestChoiceRangeCommandConflict(t *testing.T) {
+	p := NewParser("t", "")
+	p.Positional("level", nil).ChoiceRange(1, math.MaxInt)
+	p.NewCommand("7", "")
+	if _, err := p.Parse([]string{"3"}); err == nil {
+		t.Fatal("Parse succeeded with a command name inside the positional's range")
+	}
+}
+
+func TestPositionalChoices(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"start"}, false},
+		{[]string{"stop", "web"}, false},
+		{[]string{"restart"}, true},
+		{[]string{"start", "db"}, true},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "")
+		p.Positional("action", nil).Choices([]string{"start", "stop"})
+		p.Positional("service", nil).Choices([]string{"web", "worker"})
+		_, err := p.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+		}
+		var choiceErr *InvalidChoiceError
+		if err != nil && !errors.As(err, &choiceErr) {
+			t.Errorf("Parse(%q) error = %v, want an InvalidChoiceError", tt.args, err)
+		}
+	}
+}
+
+func TestFlagChoices(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"valid", []string{"--format", "json"}, false},
+		{"attached", []string{"--format=yaml"}, false},
+		{"short", []string{"-f", "xml"}, true},
+		{"case sensitive", []string{"--format", "JSON"}, true},
+		{"valid list", []string{"--tags", "a,b"}, false},
+		{"invalid list element", []string{"--tags", "a,z"}, true},
+		{"default not checked", []string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("f", "format", nil).Choices([]string{"json", "yaml"}).Default("text")
+			p.List("", "tags", nil).Choices([]string{"a", "b"})
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			var choiceErr *InvalidChoiceError
+			if err != nil && !errors.As(err, &choiceErr) {
+				t.Errorf("Parse(%q) error = %v, want an InvalidChoiceError", tt.args, err)
+			}
+		})
+	}
+}
+
+func TestChoicesListingFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{"static choices", []string{"--list-choices", "format"}, 0, "json\ntext\n"},
+		{"dynamic choices", []string{"--list-choices", "profile"}, 0, "dev\nprod\n"},
+		{"unknown argument", []string{"--list-choices", "bogus"}, 1, ""},
+		{"not given", []string{}, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			p := NewParser("t", "").SetOutput(&out)
+			p.AddChoicesListingFlag()
+			p.String("", "name", nil).Required()
+			p.String("", "format", nil).Choices([]string{"json", "text"})
+			p.String("", "profile", nil).ChoicesFrom(func() []string { return []string{"dev", "prod"} })
+			if code := parseOrExit(t, p, tt.args); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.want != "" && out.String() != tt.want {
+				t.Errorf("printed %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package argparse
+
+import (
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// constructorNames maps argument types to the Parser method defining them
+var constructorNames = map[ArgumentType]string{
+	String:     "String",
+	Int:        "Int",
+	Int64:      "Int64",
+	Uint:       "Uint",
+	Float:      "Float",
+	Float32:    "Float32",
+	Bool:       "Bool",
+	List:       "List",
+	Counter:    "Counter",
+	DateTime:   "DateTime",
+	FeatureSet: "FeatureSet",
+	JSON:       "JSON",
+	Duration:   "Duration",
+	Glob:       "Glob",
+	TimeOfDay:  "TimeOfDay",
+}
+
+// GenerateGoCode returns Go statements that rebuild the parser definition:
+// NewParser, its settings, the argument constructors with their options,
+// groups and subcommands. Functions such as validators, computed defaults
+// and handlers cannot be reproduced; a comment in the code lists each one
+// left out.
+func (p *Parser) GenerateGoCode() string {
+	gen := &goGenerator{used: map[string]bool{"parser": true}}
+	fmt.Fprintf(&gen.b, "parser := argparse.NewParser(%s, %s)\n", strconv.Quote(p.name), strconv.Quote(p.description))
+	gen.parser(p, "parser")
+
+	code, err := format.Source([]byte(gen.b.String()))
+	if err != nil {
+		return gen.b.String()
+	}
+	return string(code)
+}
+
+// goGenerator accumulates generated code and the variable names taken
+type goGenerator struct {
+	b    strings.Builder
+	used map[string]bool
+}
+
+// name returns an unused variable name built from prefix, words and suffix
+func (g *goGenerator) name(prefix, words, suffix string) string {
+	base := camelCase(prefix, words)
+	if base == "" || unicode.IsDigit([]rune(base)[0]) {
+		base = strings.ToLower(suffix) + base
+	}
+	name := base + suffix
+	for i := 2; g.used[name]; i++ {
+		name = fmt.Sprintf("%s%d%s", base, i, suffix)
+	}
+	g.used[name] = true
+	return name
+}
+
+// parser writes the settings, arguments, groups and subcommands of the
+// parser held in the variable v
+func (g *goGenerator) parser(p *Parser, v string) {
+	b := &g.b
+	if p.epilog != "" {
+		fmt.Fprintf(b, "%s.SetEpilog(%s)\n", v, strconv.Quote(p.epilog))
+	}
+	if p.version != Version && (p.parent == nil || p.version != p.parent.version) {
+		fmt.Fprintf(b, "%s.SetVersion(%s)\n", v, strconv.Quote(p.version))
+	}
+	for _, call := range goSettings(p) {
+		fmt.Fprintf(b, "%s%s\n", v, call)
+	}
+	if missing := goUnsupported(p); len(missing) > 0 {
+		fmt.Fprintf(b, "// Not reproduced: %s\n", strings.Join(missing, ", "))
+	}
+
+	prefix := strings.TrimSuffix(v, "Cmd")
+	if v == "parser" {
+		prefix = ""
+	}
+
+	// Help groups receive their members' constructors
+	receivers := make(map[*Argument]string)
+	for _, group := range p.argGroups {
+		groupVar := g.name(prefix, group.title, "Group")
+		fmt.Fprintf(b, "%s := %s.NewArgumentGroup(%s)\n", groupVar, v, strconv.Quote(group.title))
+		for _, arg := range group.args {
+			receivers[arg] = groupVar
+		}
+	}
+
+	// Members of count groups are kept in variables to add them to the group
+	vars := make(map[*Argument]string)
+	for _, group := range p.groups {
+		for _, arg := range group.members {
+			if _, ok := vars[arg]; !ok && arg.parent == p {
+				name := arg.Name
+				if name == "" {
+					name = arg.ShortName
+				}
+				vars[arg] = g.name(prefix, name, "Arg")
+			}
+		}
+	}
+
+	for _, arg := range p.args {
+		receiver, ok := receivers[arg]
+		if !ok {
+			receiver = v
+		}
+		assign := ""
+		if name, ok := vars[arg]; ok {
+			assign = name + " := "
+		}
+		if missing := goArgUnsupported(arg); len(missing) > 0 {
+			fmt.Fprintf(b, "// Not reproduced for --%s: %s\n", arg.Name, strings.Join(missing, ", "))
+		}
+		switch {
+		case arg.Name == "help" && arg.ShortName == "h" && arg.Description == p.msgs().HelpDescription:
+			fmt.Fprintf(b, "%s%s.AddHelp()\n", assign, v)
+		case arg.Name == "version" && arg.ShortName == "V" && arg.Description == p.msgs().VersionDescription:
+			fmt.Fprintf(b, "%s%s.AddVersion()\n", assign, v)
+		case arg == p.choicesFlag:
+			fmt.Fprintf(b, "%s%s.AddChoicesListingFlag()\n", assign, v)
+		default:
+			method, ok := constructorNames[arg.ArgType]
+			if !ok {
+				method = "Flag"
+			}
+			fmt.Fprintf(b, "%s%s.%s(%s, %s, %s)%s\n", assign, receiver, method, strconv.Quote(arg.ShortName), strconv.Quote(arg.Name), goArgument(arg), goModifiers(arg))
+		}
+	}
+	for _, pos := range p.positional {
+		if missing := goArgUnsupported(pos); len(missing) > 0 {
+			fmt.Fprintf(b, "// Not reproduced for %s: %s\n", pos.Name, strings.Join(missing, ", "))
+		}
+		fmt.Fprintf(b, "%s.Positional(%s, %s)%s\n", v, strconv.Quote(pos.Name), goArgument(pos), goModifiers(pos))
+	}
+
+	for _, group := range p.groups {
+		members := make([]string, 0, len(group.members))
+		for _, arg := range group.members {
+			if name, ok := vars[arg]; ok {
+				members = append(members, name)
+			}
+		}
+		if group.exactlyOne() {
+			fmt.Fprintf(b, "%s.NewExactlyOneGroup().Add(%s)\n", v, strings.Join(members, ", "))
+		} else {
+			fmt.Fprintf(b, "%s.NewCountRangeGroup(%d, %d).Add(%s)\n", v, group.min, group.max, strings.Join(members, ", "))
+		}
+	}
+	for _, req := range p.requires {
+		method := "RequireOneOf"
+		if req.all {
+			method = "RequireTogether"
+		}
+		fmt.Fprintf(b, "%s.%s(%s)\n", v, method, goStrings(req.names))
+	}
+
+	for _, name := range p.commands {
+		sub := p.subparsers[name]
+		subVar := g.name(prefix, name, "Cmd")
+		fmt.Fprintf(b, "\n%s := %s.NewCommand(%s, %s).Parser\n", subVar, v, strconv.Quote(name), strconv.Quote(sub.description))
+		g.parser(sub, subVar)
+	}
+	if p.defaultCmd != "" {
+		fmt.Fprintf(b, "%s.SetDefaultCommand(%s)\n", v, strconv.Quote(p.defaultCmd))
+	}
+}
+
+// goSettings returns the calls reproducing the parser's plain settings
+func goSettings(p *Parser) []string {
+	settings := []struct {
+		set  bool
+		call string
+	}{
+		{p.noExit, ".SetExitOnHelp(false)"},
+		{p.helpOnEmpty, ".HelpOnEmpty(true)"},
+		{p.requireEq, ".RequireEquals(true)"},
+		{p.maxArgs > 0, fmt.Sprintf(".SetMaxArgs(%d)", p.maxArgs)},
+		{p.noAbbrev, ".AllowAbbrev(false)"},
+		{p.tolerant, ".TolerateUnknown(true)"},
+		{p.emptyUnset, ".TreatEmptyAsUnset(true)"},
+		{p.chaining, ".AllowCommandChaining(true)"},
+		{p.compactHelp, ".CompactHelp(true)"},
+		{p.noDefaults, ".ShowDefaults(false)"},
+		{p.seeAlso, ".ShowSeeAlso(true)"},
+		{p.helpWidth > 0, fmt.Sprintf(".SetHelpWidth(%d)", p.helpWidth)},
+		{p.maxChoices > 0, fmt.Sprintf(".SetMaxInlineChoices(%d)", p.maxChoices)},
+		{p.gccErrors, ".GCCStyleErrors(true)"},
+		{p.errVerbose != ErrorHelp, fmt.Sprintf(".SetErrorVerbosity(argparse.ErrorVerbosity(%d))", p.errVerbose)},
+		{p.helpExit != 0, fmt.Sprintf(".SetHelpExitCode(%d)", p.helpExit)},
+	}
+	calls := make([]string, 0)
+	for _, setting := range settings {
+		if setting.set {
+			calls = append(calls, setting.call)
+		}
+	}
+	if len(p.exitCodes) > 0 {
+		kinds := make([]int, 0, len(p.exitCodes))
+		for kind := range p.exitCodes {
+			kinds = append(kinds, int(kind))
+		}
+		sort.Ints(kinds)
+		entries := make([]string, len(kinds))
+		for i, kind := range kinds {
+			entries[i] = fmt.Sprintf("argparse.ErrorKind(%d): %d", kind, p.exitCodes[ErrorKind(kind)])
+		}
+		calls = append(calls, fmt.Sprintf(".SetExitCodes(map[argparse.ErrorKind]int{%s})", strings.Join(entries, ", ")))
+	}
+	return calls
+}
+
+// goUnsupported returns the parser settings made of functions or writers,
+// which generated code cannot reproduce
+func goUnsupported(p *Parser) []string {
+	settings := []struct {
+		set  bool
+		name string
+	}{
+		{p.messages != nil, "SetMessages"},
+		{len(p.preParse) > 0, "AddPreParseHook"},
+		{len(p.validators) > 0, "AddValidator"},
+		{p.handler != nil, "SetHandler"},
+		{p.preRun != nil, "SetPreRun"},
+		{p.postRun != nil, "SetPostRun"},
+		{p.preRunAll != nil, "SetPersistentPreRun"},
+		{p.postRunAll != nil, "SetPersistentPostRun"},
+		{p.widthFunc != nil, "SetWidthFunc"},
+		{p.exitFunc != nil, "SetExitFunc"},
+		{p.out != nil, "SetOutput"},
+		{p.errOut != nil, "SetErrorOutput"},
+		{p.debugOut != nil, "SetDebugOutput"},
+		{len(p.bindings) > 0, "Bind"},
+	}
+	names := make([]string, 0)
+	for _, setting := range settings {
+		if setting.set {
+			names = append(names, setting.name)
+		}
+	}
+	return names
+}
+
+// goArgUnsupported returns the argument options made of functions or values
+// without a Go literal, which generated code cannot reproduce
+func goArgUnsupported(arg *Argument) []string {
+	_, literal := goLiteral(arg.DefaultVal)
+	options := []struct {
+		set  bool
+		name string
+	}{
+		{arg.DefaultVal != nil && !literal && !goZero(arg), "Default"},
+		{arg.DefaultFunc != nil, "DefaultFrom"},
+		{arg.ChoicesFunc != nil, "ChoicesFrom"},
+		{len(arg.Validators) > 0, "Validate"},
+		{len(arg.Pipeline) > 0, "Pipe"},
+	}
+	names := make([]string, 0)
+	for _, option := range options {
+		if option.set {
+			names = append(names, option.name)
+		}
+	}
+	return names
+}
+
+// goZero reports whether a default is the zero value a constructor sets
+// itself, which needs no code
+func goZero(arg *Argument) bool {
+	v := reflect.ValueOf(arg.DefaultVal)
+	return v.IsZero() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0
+}
+
+// goStrings returns quoted strings separated by commas
+func goStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// goArgument returns an &argparse.Argument{...} literal with the argument's
+// plain options, or nil when it has none
+func goArgument(arg *Argument) string {
+	fields := make([]string, 0)
+	add := func(name, value string) {
+		fields = append(fields, name+": "+value)
+	}
+	if arg.Description != "" {
+		add("Description", strconv.Quote(arg.Description))
+	}
+	if arg.IsRequired && arg.FixedCount == 0 {
+		add("IsRequired", "true")
+	}
+	if _, ok := constructorNames[arg.ArgType]; !ok || arg.isPositional && arg.ArgType != String {
+		add("ArgType", goArgType(arg.ArgType))
+	}
+	// Bool sets its own default, so true is set with Default instead
+	if value, ok := goLiteral(arg.DefaultVal); ok && (arg.ArgType != Bool || arg.isPositional) {
+		add("DefaultVal", value)
+	}
+	if len(arg.ValidChoices) > 0 {
+		add("ValidChoices", fmt.Sprintf("%#v", arg.ValidChoices))
+	}
+	if arg.Metavar != "" {
+		add("Metavar", strconv.Quote(arg.Metavar))
+	}
+	if arg.EnvVar != "" {
+		add("EnvVar", strconv.Quote(arg.EnvVar))
+	}
+	if arg.Separator != "" {
+		add("Separator", strconv.Quote(arg.Separator))
+	}
+	if arg.CustomType != "" {
+		add("CustomType", strconv.Quote(arg.CustomType))
+	}
+	if len(fields) == 0 {
+		return "nil"
+	}
+	return "&argparse.Argument{" + strings.Join(fields, ", ") + "}"
+}
+
+// goModifiers returns the modifier calls for options set through methods
+func goModifiers(arg *Argument) string {
+	var b strings.Builder
+	if arg.ArgType == Bool && !arg.isPositional && arg.DefaultVal == true {
+		b.WriteString(".Default(true)")
+	}
+	switch {
+	case len(arg.OnlyCommands) > 0:
+		fmt.Fprintf(&b, ".OnlyFor(%s)", goStrings(arg.OnlyCommands))
+	case arg.IsPersistent:
+		b.WriteString(".Persistent()")
+	}
+	if arg.FixedCount > 0 {
+		fmt.Fprintf(&b, ".Count(%d)", arg.FixedCount)
+	}
+	flags := []struct {
+		set  bool
+		call string
+	}{
+		{arg.IsSensitive, ".Sensitive()"},
+		{arg.IsGreedy, ".Greedy()"},
+		{arg.IsTerminator, ".Terminates()"},
+		{arg.IsVariadic, ".Variadic()"},
+		{arg.IsRemainder, ".Remainder()"},
+		{arg.IsAppend, ".Append()"},
+		{arg.MustMatch, ".RequireMatch()"},
+		{arg.EmptyIsUnset, ".TreatEmptyAsUnset()"},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			b.WriteString(flag.call)
+		}
+	}
+	if arg.MinValue != nil {
+		fmt.Fprintf(&b, ".Min(%s)", strconv.FormatFloat(*arg.MinValue, 'g', -1, 64))
+	}
+	if arg.MaxValue != nil {
+		fmt.Fprintf(&b, ".Max(%s)", strconv.FormatFloat(*arg.MaxValue, 'g', -1, 64))
+	}
+	if arg.choiceRange != nil {
+		fmt.Fprintf(&b, ".ChoiceRange(%d, %d)", arg.choiceRange[0], arg.choiceRange[1])
+	}
+	if arg.Units != nil {
+		fmt.Fprintf(&b, ".Unit(%s, %#v)", strconv.Quote(arg.BaseUnit), arg.Units)
+	}
+	if len(arg.Layouts) > 0 {
+		layouts := make([]string, len(arg.Layouts))
+		for i, layout := range arg.Layouts {
+			layouts[i] = strconv.Quote(layout)
+			if layout == LayoutUnix {
+				layouts[i] = "argparse.LayoutUnix"
+			}
+		}
+		fmt.Fprintf(&b, ".Layout(%s)", strings.Join(layouts, ", "))
+	}
+	if arg.ReplacedBy != "" {
+		fmt.Fprintf(&b, ".DeprecatedInFavorOf(%s)", strconv.Quote(arg.ReplacedBy))
+	}
+	return b.String()
+}
+
+// goArgType returns the Go expression for an argument type
+func goArgType(argType ArgumentType) string {
+	if name, ok := constructorNames[argType]; ok {
+		return "argparse." + name
+	}
+	return fmt.Sprintf("argparse.ArgumentType(%d)", argType)
+}
+
+// goLiteral returns a Go literal for a default value. Zero values the
+// constructors set themselves, and types without a literal form, are skipped.
+func goLiteral(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v), true
+	case int:
+		return strconv.Itoa(v), v != 0
+	case int64:
+		return fmt.Sprintf("int64(%d)", v), true
+	case uint64:
+		return fmt.Sprintf("uint64(%d)", v), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case float32:
+		return fmt.Sprintf("float32(%s)", strconv.FormatFloat(float64(v), 'g', -1, 32)), true
+	case bool:
+		return "true", v
+	case []string:
+		return fmt.Sprintf("%#v", v), len(v) > 0
+	case time.Duration:
+		return fmt.Sprintf("time.Duration(%d)", int64(v)), true
+	case time.Time:
+		return fmt.Sprintf("time.Unix(%d, %d).UTC()", v.Unix(), v.Nanosecond()), !v.IsZero()
+	case []int, map[string]bool, map[string]float64:
+		return fmt.Sprintf("%#v", v), reflect.ValueOf(v).Len() > 0
+	}
+	return "", false
+}
+
+// camelCase joins prefix and the words of name into a Go identifier, such
+// as remote and "add-url" into remoteAddUrl
+func camelCase(prefix, name string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	upper := prefix != ""
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		} else if b.Len() == 0 {
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
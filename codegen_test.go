@@ -0,0 +1,39 @@
+package argparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateGoCode(t *testing.T) {
+	p := NewParser("t", "test tool")
+	p.Bool("", "color", nil).Default(true)
+	p.Int("", "size", nil).Unit("B", map[string]float64{"K": 1024})
+	p.DateTime("", "since", nil).Layout("2006-01-02", LayoutUnix)
+	p.String("", "name", nil).TreatEmptyAsUnset()
+	p.NewArgumentGroup("Output").String("o", "output", nil)
+	p.NewExactlyOneGroup().Add(p.Bool("", "json", nil), p.Bool("", "text", nil))
+	p.String("", "user", nil).Validate(func(interface{}) error { return nil })
+	p.NewCommand("add", "").Parser.String("", "title", nil)
+
+	code := p.GenerateGoCode()
+	for _, want := range []string{
+		`parser.Bool("", "color", nil).Default(true)`,
+		`.Unit("B", map[string]float64{"K": 1024})`,
+		`.Layout("2006-01-02", argparse.LayoutUnix)`,
+		`.TreatEmptyAsUnset()`,
+		`outputGroup := parser.NewArgumentGroup("Output")`,
+		`outputGroup.String("o", "output", nil)`,
+		`parser.NewExactlyOneGroup().Add(jsonArg, textArg)`,
+		`// Not reproduced for --user: Validate`,
+		`addCmd := parser.NewCommand("add", "").Parser`,
+		`addCmd.String("", "title", nil)`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("GenerateGoCode() is missing %q in:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "DefaultVal: true") {
+		t.Errorf("GenerateGoCode() puts a Bool default in the literal:\n%s", code)
+	}
+}
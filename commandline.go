@@ -0,0 +1,229 @@
+package argparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maskedValue replaces the value of sensitive arguments
+const maskedValue = "****"
+
+// ReconstructCommandLine builds a shell-safe command line equivalent to the
+// last parse, including default values, so a run can be reproduced.
+// Values of sensitive arguments are masked.
+func (p *Parser) ReconstructCommandLine() string {
+	if p.results() == nil {
+		return shellQuote(p.name)
+	}
+
+	tokens := append([]string{shellQuote(p.name)}, p.reconstruct(p.result)...)
+	return strings.Join(tokens, " ")
+}
+
+// reconstruct returns the quoted tokens for this parser and the selected subcommand
+func (p *Parser) reconstruct(result map[string]interface{}) []string {
+	tokens := make([]string, 0)
+
+	for _, arg := range p.args {
+		if arg.Name == "help" || arg.Name == "version" {
+			continue
+		}
+		val, ok := result[arg.Name]
+		if !ok || val == nil {
+			continue
+		}
+
+		flag := "--" + arg.Name
+		if arg.Name == "" {
+			flag = "-" + arg.ShortName
+		}
+		switch arg.ArgType {
+		case Bool:
+			b, _ := val.(bool)
+			if b {
+				tokens = append(tokens, flag)
+			} else if arg.DefaultVal == true && arg.negatable() && p.findOption(func(option *Argument) bool { return option.Name == "no-"+arg.Name }) == nil {
+				tokens = append(tokens, "--no-"+arg.Name)
+			}
+		case Counter:
+			count, _ := val.(int)
+			for i := 0; i < count; i++ {
+				tokens = append(tokens, flag)
+			}
+		default:
+			if list, ok := val.([]string); ok && len(list) == 0 {
+				continue
+			}
+			for _, value := range p.valuesOf(arg, val) {
+				tokens = append(tokens, p.flagTokens(arg, flag, value)...)
+			}
+		}
+	}
+
+	if names := p.selectedCommands(); len(names) > 0 {
+		for _, name := range names {
+			subparser := p.subparsers[name]
+			values := result
+			if len(p.chain) > 0 {
+				values = subparser.result
+			}
+			tokens = append(tokens, shellQuote(name))
+			tokens = append(tokens, subparser.reconstruct(values)...)
+		}
+		return tokens
+	}
+
+	positionals := make([]string, 0)
+	separate := false
+	for _, pos := range p.positional {
+		val, ok := result[pos.Name]
+		if !ok || val == nil {
+			continue
+		}
+		for _, value := range p.valuesOf(pos, val) {
+			if pos.IsSensitive {
+				positionals = append(positionals, maskedValue)
+				continue
+			}
+			// Values that would read as flags or escapes follow a "--"
+			text := p.formatArg(pos, value)
+			if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "\\-") {
+				separate = true
+			}
+			positionals = append(positionals, shellQuote(text))
+		}
+	}
+	if separate {
+		tokens = append(tokens, "--")
+	}
+	return append(tokens, positionals...)
+}
+
+// valuesOf splits an argument's value into the values given one token or
+// flag each: the elements of append-mode flags and collecting or remainder
+// positionals, and the patterns typed for a Glob rather than its matches
+func (p *Parser) valuesOf(arg *Argument, val interface{}) []interface{} {
+	if arg.ArgType == Glob && arg.isSet && len(p.raw[arg.Name]) > 0 {
+		patterns := p.raw[arg.Name]
+		if !arg.IsAppend && !arg.collects() {
+			patterns = patterns[len(patterns)-1:]
+		}
+		values := make([]interface{}, len(patterns))
+		for i, pattern := range patterns {
+			values[i] = pattern
+		}
+		return values
+	}
+
+	multiple := arg.IsRemainder || arg.collects() || arg.IsAppend && arg.ArgType != List
+	if v := reflect.ValueOf(val); multiple && v.Kind() == reflect.Slice {
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).Interface()
+		}
+		return values
+	}
+	return []interface{}{val}
+}
+
+// flagTokens returns the tokens giving a flag one value, as --name=value when
+// the value starts with "-" or "\-" and would otherwise read as a flag or be
+// unescaped, or when the flag is greedy and would take the tokens after it too
+func (p *Parser) flagTokens(arg *Argument, flag string, val interface{}) []string {
+	if arg.IsGreedy && arg.IsSensitive {
+		return []string{flag + "=" + maskedValue}
+	}
+	text := p.formatArg(arg, val)
+	if arg.IsGreedy || !arg.IsSensitive && (strings.HasPrefix(text, "-") || strings.HasPrefix(text, "\\-")) {
+		return []string{shellQuote(flag + "=" + text)}
+	}
+	return []string{flag, p.quoteValue(arg, val)}
+}
+
+// quoteValue formats and quotes an argument value, masking sensitive ones
+func (p *Parser) quoteValue(arg *Argument, val interface{}) string {
+	if arg.IsSensitive {
+		return maskedValue
+	}
+	return shellQuote(p.formatArg(arg, val))
+}
+
+// formatArg converts an argument's value into its command-line form, using
+// the argument's own separator and date layouts
+func (p *Parser) formatArg(arg *Argument, val interface{}) string {
+	if d, ok := val.(time.Duration); ok && arg.ArgType == TimeOfDay {
+		return formatTimeOfDay(d)
+	}
+	if list, ok := val.([]string); ok && arg.ArgType == List {
+		return strings.Join(list, arg.separator())
+	}
+	if t, ok := val.(time.Time); ok && len(arg.Layouts) > 0 {
+		return formatDateTime(t, arg.Layouts)
+	}
+	return formatValue(val)
+}
+
+// formatDateTime formats t with the first of layouts that keeps its value,
+// or the first layout if none does
+func formatDateTime(t time.Time, layouts []string) string {
+	format := func(layout string) string {
+		if layout == LayoutUnix {
+			return strconv.FormatInt(t.Unix(), 10)
+		}
+		return t.Format(layout)
+	}
+	defaults := DefaultMessages()
+	for _, layout := range layouts {
+		if parsed, err := parseDateTime(format(layout), []string{layout}, &defaults); err == nil && parsed.(time.Time).Equal(t) {
+			return format(layout)
+		}
+	}
+	return format(layouts[0])
+}
+
+// formatValue converts a parsed value back into its command-line form
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case map[string]bool:
+		names := make([]string, 0, len(v))
+		for name, enabled := range v {
+			if enabled {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// shellQuote quotes a token for POSIX shells when it contains special characters
+func shellQuote(token string) string {
+	if token == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range token {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@%+", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return token
+	}
+	return "'" + strings.ReplaceAll(token, "'", `'\''`) + "'"
+}
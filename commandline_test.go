@@ -0,0 +1,237 @@
+package argparse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shellSplit splits a command line produced by ReconstructCommandLine,
+// undoing shellQuote's single quoting
+func shellSplit(line string) []string {
+	tokens := make([]string, 0)
+	var token strings.Builder
+	inToken, quoted := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted && c == '\'':
+			quoted = false
+		case quoted:
+			token.WriteByte(c)
+		case c == '\'':
+			quoted, inToken = true, true
+		case c == '\\' && i+1 < len(line):
+			i++
+			token.WriteByte(line[i])
+			inToken = true
+		case c == ' ':
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteByte(c)
+			inToken = true
+		}
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+func TestReconstructCommandLine(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pattern := filepath.Join(dir, "*.log")
+
+	tests := []struct {
+		name   string
+		define func(p *Parser)
+		args   []string
+		want   string
+	}{
+		{
+			"negated bool",
+			func(p *Parser) { p.Bool("", "color", nil).Default(true) },
+			[]string{"--no-color"},
+			"t --no-color",
+		},
+		{
+			"bool at its default",
+			func(p *Parser) { p.Bool("", "color", nil).Default(true) },
+			[]string{},
+			"t --color",
+		},
+		{
+			"datetime layout",
+			func(p *Parser) { p.DateTime("", "when", nil).Layout("02-Jan-2006") },
+			[]string{"--when", "05-Mar-2024"},
+			"t --when 05-Mar-2024",
+		},
+		{
+			"unix datetime",
+			func(p *Parser) { p.DateTime("", "when", nil).Layout(LayoutUnix) },
+			[]string{"--when", "1700000000"},
+			"t --when 1700000000",
+		},
+		{
+			"append int",
+			func(p *Parser) { p.Int("", "num", nil).Append() },
+			[]string{"--num", "1", "--num", "2"},
+			"t --num 1 --num 2",
+		},
+		{
+			"negative value",
+			func(p *Parser) { p.Int("", "offset", nil) },
+			[]string{"--offset", "-5"},
+			"t --offset=-5",
+		},
+		{
+			"variadic positional",
+			func(p *Parser) { p.Positional("files", nil).Variadic() },
+			[]string{"a", "b c", "d"},
+			"t a 'b c' d",
+		},
+		{
+			"counted positional",
+			func(p *Parser) { p.Positional("point", nil).Count(2) },
+			[]string{"1", "2"},
+			"t 1 2",
+		},
+		{
+			"remainder",
+			func(p *Parser) { p.Positional("rest", nil).Remainder() },
+			[]string{"--", "-x", "y"},
+			"t -- -x y",
+		},
+		{
+			"glob",
+			func(p *Parser) { p.Glob("", "logs", nil) },
+			[]string{"--logs", pattern},
+			"t --logs " + shellQuote(pattern),
+		},
+		{
+			"dash positional",
+			func(p *Parser) { p.Positional("file", nil) },
+			[]string{"--", "-x"},
+			"t -- -x",
+		},
+		{
+			"backslash positional",
+			func(p *Parser) { p.Positional("file", nil) },
+			[]string{"--", `\-x`},
+			`t -- '\-x'`,
+		},
+		{
+			"greedy before positional",
+			func(p *Parser) {
+				p.String("", "words", nil).Greedy()
+				p.Positional("file", nil)
+			},
+			[]string{"--words", "a", "b", "--", "c"},
+			"t '--words=a b' c",
+		},
+		{
+			"greedy before command",
+			func(p *Parser) {
+				p.String("", "words", nil).Greedy()
+				p.Bool("v", "verbose", nil)
+				p.NewCommand("add", "")
+			},
+			[]string{"--words", "a", "b", "-v", "add"},
+			"t '--words=a b' --verbose add",
+		},
+		{
+			"escaped flag value",
+			func(p *Parser) { p.String("o", "output", nil) },
+			[]string{`--output=\-x`},
+			`t '--output=\-x'`,
+		},
+		{
+			"escaped short value",
+			func(p *Parser) { p.String("o", "output", nil) },
+			[]string{`-o\-`},
+			`t '--output=\-'`,
+		},
+		{
+			"short-only flag",
+			func(p *Parser) { p.String("o", "", nil) },
+			[]string{"-o", "val"},
+			"t -o val",
+		},
+		{
+			"short-only flag with dash value",
+			func(p *Parser) { p.String("o", "", nil) },
+			[]string{"-o=-x"},
+			"t -o=-x",
+		},
+		{
+			"list separator",
+			func(p *Parser) { p.List("", "tags", nil).Sep(";") },
+			[]string{"--tags", "a;b"},
+			"t --tags 'a;b'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			tt.define(p)
+			want, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			line := p.ReconstructCommandLine()
+			if line != tt.want {
+				t.Errorf("ReconstructCommandLine() = %q, want %q", line, tt.want)
+			}
+
+			again := NewParser("t", "")
+			tt.define(again)
+			got, err := again.Parse(shellSplit(line)[1:])
+			if err != nil {
+				t.Fatalf("reparsing %q: %v", line, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("reparsing %q gave %v, want %v", line, got, want)
+			}
+		})
+	}
+}
+
+func TestReconstructMasksSensitive(t *testing.T) {
+	p := NewParser("t", "")
+	p.String("", "password", nil).Sensitive()
+	if _, err := p.Parse([]string{"--password=-hunter2"}); err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+	if got := p.ReconstructCommandLine(); got != "t --password ****" {
+		t.Errorf("ReconstructCommandLine() = %q, want the value masked", got)
+	}
+}
+
+func TestFormatDateTime(t *testing.T) {
+	when := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		layouts []string
+		want    string
+	}{
+		{[]string{"2006-01-02"}, "2024-03-05"},
+		{[]string{"2006-01-02", "2006-01-02 15:04"}, "2024-03-05 14:30"},
+		{[]string{LayoutUnix}, "1709649000"},
+	}
+	for _, tt := range tests {
+		if got := formatDateTime(when, tt.layouts); got != tt.want {
+			t.Errorf("formatDateTime(%v) = %q, want %q", tt.layouts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package argparse
+
+import (
+	"sort"
+	"strings"
+)
+
+// CompleteLine returns completion candidates for the last word of words, the
+// command line after the program name. Flags are offered for words starting
+// with "-", otherwise subcommands and positional choices. After a bare "--"
+// nothing is offered, leaving the shell to complete file names.
+func (p *Parser) CompleteLine(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	if len(words) > 1 {
+		if subparser, ok := p.subparsers[words[0]]; ok {
+			return subparser.CompleteLine(words[1:])
+		}
+	}
+
+	current := words[len(words)-1]
+	positionalIndex, filled := 0, 0
+	var pending *Argument
+	for _, word := range words[:len(words)-1] {
+		if pending != nil {
+			pending = nil
+			continue
+		}
+		if word == "--" {
+			return nil
+		}
+		if strings.HasPrefix(word, "-") && word != "-" && !(isNegativeNumber(word) && !p.hasNumericShort()) {
+			pending = p.completionValueFlag(word)
+			continue
+		}
+		if positionalIndex < len(p.positional) && !p.positional[positionalIndex].IsVariadic {
+			if filled++; filled >= p.positional[positionalIndex].FixedCount {
+				positionalIndex, filled = positionalIndex+1, 0
+			}
+		}
+	}
+
+	// The word is the value of the preceding flag
+	if pending != nil {
+		return matchPrefix(pending.choices(), current)
+	}
+
+	candidates := make([]string, 0)
+	if strings.HasPrefix(current, "-") {
+		for _, option := range p.options() {
+			if option.parent != p && !option.allowedIn(p.name) {
+				continue
+			}
+			if option.Name != "" {
+				candidates = append(candidates, "--"+option.Name)
+			}
+			if option.ShortName != "" {
+				candidates = append(candidates, "-"+option.ShortName)
+			}
+		}
+		return matchPrefix(candidates, current)
+	}
+
+	if len(words) == 1 {
+		candidates = append(candidates, p.commands...)
+	}
+	if positionalIndex < len(p.positional) {
+		candidates = append(candidates, p.positional[positionalIndex].choices()...)
+	}
+	return matchPrefix(candidates, current)
+}
+
+// completionValueFlag returns the flag named by word if it still expects its
+// value in the next word
+func (p *Parser) completionValueFlag(word string) *Argument {
+	if strings.HasPrefix(word, "--") {
+		name, _, hasValue := strings.Cut(word[2:], "=")
+		if hasValue {
+			return nil
+		}
+		if option := p.findOption(func(option *Argument) bool { return option.Name == name }); option != nil && option.takesValue() {
+			return option
+		}
+		return nil
+	}
+
+	// Only a single short flag, or the last of a cluster, can take the next word
+	short := word[1:]
+	if strings.Contains(short, "=") {
+		return nil
+	}
+	for i, r := range short {
+		option := p.findOption(func(option *Argument) bool { return option.ShortName == string(r) })
+		if option == nil || !option.takesValue() {
+			continue
+		}
+		if i+len(string(r)) == len(short) {
+			return option
+		}
+		return nil
+	}
+	return nil
+}
+
+// takesValue reports whether the flag consumes a value
+func (a *Argument) takesValue() bool {
+	return a.ArgType != Bool && a.ArgType != Counter
+}
+
+// matchPrefix returns the sorted candidates starting with prefix
+func matchPrefix(candidates []string, prefix string) []string {
+	matches := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
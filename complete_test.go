@@ -0,0 +1,39 @@
+package argparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompleteLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{"flags", []string{"--"}, []string{"--format", "--verbose"}},
+		{"short flags", []string{"-"}, []string{"--format", "--verbose", "-f", "-v"}},
+		{"flag value", []string{"--format", "j"}, []string{"json"}},
+		{"commands", []string{""}, []string{"add", "list"}},
+		{"subcommand flags", []string{"add", "--"}, []string{"--force", "--format", "--verbose"}},
+		{"after separator", []string{"--", "-"}, nil},
+		{"after separator in subcommand", []string{"add", "--", "--f"}, nil},
+		{"separator as value", []string{"--format", "--", "--v"}, []string{"--verbose"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("v", "verbose", nil).Persistent()
+			p.String("f", "format", nil).Choices([]string{"json", "text"}).Persistent()
+			p.NewCommand("add", "").Parser.Bool("", "force", nil)
+			p.NewCommand("list", "")
+			got := p.CompleteLine(tt.words)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompleteLine(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+}
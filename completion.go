@@ -0,0 +1,120 @@
+package argparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateCompletion returns a static completion script for "bash" or "zsh"
+// covering the flags, subcommands and choice values of the parser. Flags with
+// choices complete their values, and subcommands complete their own flags.
+func (p *Parser) GenerateCompletion(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return p.bashCompletion(), nil
+	case "zsh":
+		// zsh runs the bash function through its bash compatibility layer
+		return "#compdef " + p.name + "\n\nautoload -U +X bashcompinit && bashcompinit\n\n" + p.bashCompletion(), nil
+	default:
+		return "", fmt.Errorf(p.msgs().UnsupportedShell, shell)
+	}
+}
+
+// bashCompletion builds the bash completion function and its registration
+func (p *Parser) bashCompletion() string {
+	fn := "_" + shellIdentifier(p.name) + "_complete"
+	commands := p.commandPaths("")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n", p.name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur prev cmdpath i\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    cmdpath=\"\"\n")
+	b.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("        [[ \"${COMP_WORDS[i]}\" == \"--\" ]] && return 0\n")
+	if len(commands) > 1 {
+		b.WriteString("        case \"$cmdpath/${COMP_WORDS[i]}\" in\n")
+		quoted := make([]string, 0, len(commands)-1)
+		for _, path := range commands[1:] {
+			quoted = append(quoted, shellQuote(path.path))
+		}
+		fmt.Fprintf(&b, "            %s) cmdpath=\"$cmdpath/${COMP_WORDS[i]}\" ;;\n", strings.Join(quoted, "|"))
+		b.WriteString("        esac\n")
+	}
+	b.WriteString("    done\n\n")
+
+	b.WriteString("    case \"$cmdpath\" in\n")
+	for _, command := range commands {
+		fmt.Fprintf(&b, "        %s)\n", shellQuote(command.path))
+		command.parser.writeBashCase(&b)
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, p.name)
+	return b.String()
+}
+
+// commandPath is a parser with its path of subcommand names, like "/remote/add"
+type commandPath struct {
+	path   string
+	parser *Parser
+}
+
+// commandPaths lists the parser and every subcommand beneath it, depth first
+func (p *Parser) commandPaths(prefix string) []commandPath {
+	paths := []commandPath{{prefix, p}}
+	for _, name := range p.commands {
+		paths = append(paths, p.subparsers[name].commandPaths(prefix+"/"+name)...)
+	}
+	return paths
+}
+
+// writeBashCase writes the completions of one command: the choices of the
+// flag just typed, otherwise its flags, subcommands and positional choices
+func (p *Parser) writeBashCase(b *strings.Builder) {
+	words := make([]string, 0)
+	var choiceCases []string
+	for _, option := range p.options() {
+		if option.parent != p && !option.allowedIn(p.name) {
+			continue
+		}
+		names := make([]string, 0, 2)
+		if option.Name != "" {
+			names = append(names, "--"+option.Name)
+		}
+		if option.ShortName != "" {
+			names = append(names, "-"+option.ShortName)
+		}
+		words = append(words, names...)
+		if choices := option.choices(); len(choices) > 0 && option.takesValue() {
+			choiceCases = append(choiceCases, fmt.Sprintf("                %s) COMPREPLY=($(compgen -W %s -- \"$cur\")); return 0 ;;\n",
+				strings.Join(names, "|"), shellQuote(strings.Join(choices, " "))))
+		}
+	}
+	words = append(words, p.commands...)
+	if len(p.positional) > 0 {
+		words = append(words, p.positional[0].choices()...)
+	}
+
+	if len(choiceCases) > 0 {
+		b.WriteString("            case \"$prev\" in\n")
+		for _, c := range choiceCases {
+			b.WriteString(c)
+		}
+		b.WriteString("            esac\n")
+	}
+	fmt.Fprintf(b, "            COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(words, " ")))
+}
+
+// shellIdentifier turns a program name into a valid shell function name part
+func shellIdentifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,44 @@
+package argparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateCompletion(t *testing.T) {
+	p := NewParser("my-tool", "")
+	p.Bool("v", "verbose", nil)
+	p.String("f", "format", nil).Choices([]string{"json", "text"})
+	add := p.NewCommand("add", "").Parser
+	add.Bool("", "force", nil)
+
+	common := []string{
+		"_my_tool_complete() {",
+		`--format|-f) COMPREPLY=($(compgen -W 'json text' -- "$cur")); return 0 ;;`,
+		`COMPREPLY=($(compgen -W '--verbose -v --format -f add' -- "$cur"))`,
+		"/add)\n            COMPREPLY=($(compgen -W --force -- \"$cur\"))",
+		"complete -o default -F _my_tool_complete my-tool\n",
+	}
+	tests := []struct {
+		shell   string
+		want    []string
+		wantErr bool
+	}{
+		{"bash", append([]string{"# bash completion for my-tool\n"}, common...), false},
+		{"zsh", append([]string{"#compdef my-tool\n", "bashcompinit"}, common...), false},
+		{"fish", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			script, err := p.GenerateCompletion(tt.shell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateCompletion(%q) error = %v, want error %v", tt.shell, err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(script, want) {
+					t.Errorf("script is missing %q:\n%s", want, script)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package argparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UnknownKeysError is returned by LoadConfig for keys that name no argument.
+// The other keys have still been applied, so callers may treat it as a warning.
+type UnknownKeysError struct {
+	Path string
+	Keys []string
+
+	format string // Messages.UnknownConfigKeys of the parser
+}
+
+// Error returns the error message
+func (e *UnknownKeysError) Error() string {
+	format := e.format
+	if format == "" {
+		format = DefaultMessages().UnknownConfigKeys
+	}
+	return fmt.Sprintf(format, e.Path, strings.Join(e.Keys, ", "))
+}
+
+// LoadConfig reads a JSON object from path and sets each value as the
+// default of the argument with the same long name, so the command line and
+// environment still take precedence. Values are converted like command-line
+// values; arrays become lists, or one value each for append-mode flags and
+// collecting positionals. A key naming a subcommand holds an object with
+// that command's values. Keys matching no argument are reported together as
+// an *UnknownKeysError after the rest are applied.
+func (p *Parser) LoadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf(p.msgs().InvalidConfig, path, err)
+	}
+
+	unknown, err := p.applyConfig(path, values, "")
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return &UnknownKeysError{Path: path, Keys: unknown, format: p.msgs().UnknownConfigKeys}
+	}
+	return nil
+}
+
+// applyConfig sets the defaults of p and its subcommands from values and
+// returns the keys matching nothing, prefixed with their command path as in
+// "add.title"
+func (p *Parser) applyConfig(path string, values map[string]json.RawMessage, prefix string) ([]string, error) {
+	byName := make(map[string]*Argument)
+	for _, arg := range append(append([]*Argument{}, p.args...), p.positional...) {
+		byName[arg.Name] = arg
+	}
+
+	unknown := make([]string, 0)
+	for key, raw := range values {
+		arg, ok := byName[key]
+		if subparser, isCommand := p.subparsers[key]; !ok && isCommand {
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &nested); err != nil {
+				return nil, fmt.Errorf(p.msgs().InvalidConfigValue, path, prefix+key, err)
+			}
+			keys, err := subparser.applyConfig(path, nested, prefix+key+".")
+			if err != nil {
+				return nil, err
+			}
+			unknown = append(unknown, keys...)
+			continue
+		}
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		value, err := configValue(arg, raw)
+		if err != nil {
+			return nil, fmt.Errorf(p.msgs().InvalidConfigValue, path, prefix+key, err)
+		}
+		arg.DefaultVal = value
+	}
+	return unknown, nil
+}
+
+// configValue converts a JSON config value for arg through its usual parsing
+func configValue(arg *Argument, raw json.RawMessage) (interface{}, error) {
+	if arg.ArgType == JSON && arg.CustomType == "" {
+		return parseValue(JSON, string(raw), arg.parent.msgs())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	var text string
+	switch v := decoded.(type) {
+	case string:
+		text = v
+	case []interface{}:
+		return configList(arg, v)
+	case nil:
+		return nil, nil
+	default:
+		text = fmt.Sprint(v)
+	}
+	if arg.ArgType == Counter {
+		return strconv.Atoi(text)
+	}
+	_, value, err := arg.convert(text)
+	return value, err
+}
+
+// configList converts a JSON array element by element: a List gets the
+// elements as its items, append-mode flags and collecting positionals a
+// slice of converted values, and other types the elements joined with the
+// argument's separator
+func configList(arg *Argument, items []interface{}) (interface{}, error) {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = fmt.Sprint(item)
+	}
+
+	switch {
+	case arg.ArgType == List && arg.CustomType == "":
+		list := make([]string, 0, len(texts))
+		for _, text := range texts {
+			_, value, err := arg.convert(text)
+			if err != nil {
+				return nil, err
+			}
+			if items, ok := value.([]string); ok {
+				list = append(list, items...)
+			} else {
+				list = append(list, text)
+			}
+		}
+		return list, nil
+	case arg.IsAppend || arg.collects():
+		if len(texts) == 0 {
+			return nil, nil
+		}
+		var slice reflect.Value
+		for i, text := range texts {
+			_, value, err := arg.convert(text)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				slice = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(value)), 0, len(texts))
+			}
+			slice = reflect.Append(slice, reflect.ValueOf(value))
+		}
+		return slice.Interface(), nil
+	default:
+		_, value, err := arg.convert(strings.Join(texts, arg.separator()))
+		return value, err
+	}
+}
@@ -0,0 +1,96 @@
+package argparse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	p := NewParser("t", "")
+	p.String("", "name", nil)
+	p.Counter("v", "verbose", nil)
+	p.List("", "tags", nil).Sep(";")
+	p.Int("", "num", nil).Append()
+	p.Positional("files", nil).Variadic()
+	add := p.NewCommand("add", "").Parser
+	add.String("", "title", nil)
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := `{
+		"name": "bob",
+		"verbose": 3,
+		"tags": ["a", "b"],
+		"num": [1, 2],
+		"files": ["x", "y"],
+		"add": {"title": "draft", "draft": true},
+		"colour": "red"
+	}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var unknown *UnknownKeysError
+	if err := p.LoadConfig(path); !errors.As(err, &unknown) {
+		t.Fatalf("LoadConfig() error = %v, want *UnknownKeysError", err)
+	}
+	if want := []string{"add.draft", "colour"}; !reflect.DeepEqual(unknown.Keys, want) {
+		t.Errorf("unknown keys = %q, want %q", unknown.Keys, want)
+	}
+
+	if _, err := p.Parse([]string{"add"}); err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"name", p.GetString("name"), "bob"},
+		{"verbose", p.GetInt("verbose"), 3},
+		{"tags", p.GetList("tags"), []string{"a", "b"}},
+		{"num", p.GetIntSlice("num"), []int{1, 2}},
+		{"title", add.GetString("title"), "draft"},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if _, err := p.Parse([]string{}); err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+	if got := p.Get("files"); !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("files = %#v, want both elements", got)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"not a number", `{"count": "many"}`},
+		{"command not an object", `{"add": 3}`},
+		{"invalid command value", `{"add": {"count": "many"}}`},
+		{"not json", `{`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Int("", "count", nil)
+			p.NewCommand("add", "").Parser.Int("", "count", nil)
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var unknown *UnknownKeysError
+			if err := p.LoadConfig(path); err == nil || errors.As(err, &unknown) {
+				t.Errorf("LoadConfig() error = %v, want a conversion error", err)
+			}
+		})
+	}
+}
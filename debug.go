@@ -0,0 +1,84 @@
+package argparse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// debugEnv enables tracing to stderr when set to a non-empty value
+const debugEnv = "ARGPARSE_DEBUG"
+
+// SetDebug traces how each token is classified and which argument it sets,
+// written to stderr. Setting ARGPARSE_DEBUG in the environment does the same.
+func (p *Parser) SetDebug(enable bool) *Parser {
+	if enable {
+		p.debugOut = os.Stderr
+	} else {
+		p.debugOut = nil
+	}
+	return p
+}
+
+// SetDebugOutput traces the parse to w instead of stderr
+func (p *Parser) SetDebugOutput(w io.Writer) *Parser {
+	p.debugOut = w
+	return p
+}
+
+// tracef writes one trace line if tracing is enabled
+func (p *Parser) tracef(format string, args ...interface{}) {
+	w := p.root().debugOut
+	if w == nil {
+		if os.Getenv(debugEnv) == "" {
+			return
+		}
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "argparse: "+format+"\n", args...)
+}
+
+// traceValue returns a value for tracing, masked for sensitive arguments
+func traceValue(arg *Argument, value interface{}) interface{} {
+	if arg.IsSensitive {
+		return maskedValue
+	}
+	return value
+}
+
+// traceToken returns a command-line token for tracing, with the value part
+// masked when it belongs to a sensitive argument: --password=****, -p****
+// or a positional's whole token
+func (p *Parser) traceToken(st *parseState, token string) string {
+	switch {
+	case st.optionsDone || !strings.HasPrefix(token, "-") || token == "-" || isNegativeNumber(token) && !p.hasNumericShort():
+		if st.positionalIndex < len(p.positional) && p.positional[st.positionalIndex].IsSensitive {
+			return maskedValue
+		}
+		if n := len(p.positional); n > 0 && st.positionalIndex >= n && p.positional[n-1].IsSensitive {
+			return maskedValue
+		}
+	case strings.HasPrefix(token, "--"):
+		name, _, hasValue := strings.Cut(token[2:], "=")
+		// An abbreviation may stand for a sensitive flag, so any match masks
+		sensitive := p.findOption(func(option *Argument) bool {
+			return option.IsSensitive && strings.HasPrefix(option.Name, name)
+		})
+		if hasValue && sensitive != nil {
+			return "--" + name + "=" + maskedValue
+		}
+	default:
+		for j, shortOpt := range token[1:] {
+			option := p.findOption(func(option *Argument) bool { return option.ShortName == string(shortOpt) })
+			if option == nil || option.ArgType != Bool && option.ArgType != Counter {
+				if option != nil && option.IsSensitive && j+utf8.RuneLen(shortOpt) < len(token)-1 {
+					return token[:1+j+utf8.RuneLen(shortOpt)] + maskedValue
+				}
+				break
+			}
+		}
+	}
+	return token
+}
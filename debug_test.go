@@ -0,0 +1,39 @@
+package argparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTraceMasksSensitiveValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"long with value", []string{"--password=hunter2"}, `"--password=****": long flag`},
+		{"long abbreviated", []string{"--pass=hunter2"}, `"--pass=****": long flag`},
+		{"long separate value", []string{"--password", "hunter2"}, `password = ****`},
+		{"short attached", []string{"-phunter2"}, `"-p****": short flag`},
+		{"short cluster", []string{"-vphunter2"}, `"-vp****": short flag`},
+		{"positional", []string{"topsecret"}, `"****": positional`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			p := NewParser("t", "").SetDebugOutput(&out)
+			p.String("p", "password", nil).Sensitive()
+			p.Bool("v", "verbose", nil)
+			p.Positional("token", nil).Sensitive()
+			if _, err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("trace = %q, want it to contain %q", out.String(), tt.want)
+			}
+			if strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "topsecret") {
+				t.Errorf("trace = %q, leaks a sensitive value", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package argparse
+
+import "os"
+
+// DefaultsSnapshot holds the default values of a parser's arguments, taken
+// with SnapshotDefaults
+type DefaultsSnapshot struct {
+	defaults map[*Argument]interface{}
+}
+
+// SnapshotDefaults records the DefaultVal of every argument of the parser
+// and its subcommands, for RestoreDefaults
+func (p *Parser) SnapshotDefaults() *DefaultsSnapshot {
+	snapshot := &DefaultsSnapshot{defaults: make(map[*Argument]interface{})}
+	for _, command := range p.commandPaths("") {
+		for _, arg := range append(append([]*Argument{}, command.parser.args...), command.parser.positional...) {
+			snapshot.defaults[arg] = arg.DefaultVal
+		}
+	}
+	return snapshot
+}
+
+// RestoreDefaults resets the DefaultVal of every argument in the snapshot to
+// its recorded value. Arguments added after the snapshot keep theirs.
+func (p *Parser) RestoreDefaults(snapshot *DefaultsSnapshot) *Parser {
+	if snapshot == nil {
+		return p
+	}
+	for arg, value := range snapshot.defaults {
+		arg.DefaultVal = value
+	}
+	return p
+}
+
+// FileExistsDefault returns a DefaultFunc giving true when path exists and
+// false otherwise, for flags gated on a sentinel file:
+//
+//	p.Bool("", "deploy", nil).DefaultFrom(argparse.FileExistsDefault("/etc/app/deploy.enabled"))
+func FileExistsDefault(path string) func(values map[string]interface{}) interface{} {
+	return func(values map[string]interface{}) interface{} {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+}
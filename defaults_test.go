@@ -0,0 +1,89 @@
+package argparse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(p *Parser)
+		args   []string
+	}{
+		{"changed defaults", func(p *Parser) {
+			p.args[0].Default("other")
+			p.positional[0].Default("y")
+		}, []string{}},
+		{"changed subcommand default", func(p *Parser) { p.subparsers["sub"].args[0].Default(9) }, []string{"sub"}},
+		{"parse in between", func(p *Parser) {
+			p.args[0].Default("other")
+			p.Parse([]string{"--name", "given"})
+		}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("", "name", nil).Default("x")
+			p.Positional("file", nil).Default("f")
+			p.NewCommand("sub", "").Parser.Int("", "level", nil).Default(1)
+			snapshot := p.SnapshotDefaults()
+			tt.mutate(p)
+			p.RestoreDefaults(snapshot)
+
+			result, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if result["name"] != "x" || result["file"] != "f" {
+				t.Errorf("name, file = %v, %v, want x, f", result["name"], result["file"])
+			}
+			if level := p.subparsers["sub"].args[0].DefaultVal; level != 1 {
+				t.Errorf("sub level default = %v, want 1", level)
+			}
+		})
+	}
+}
+
+func TestRestoreDefaultsKeepsNewArguments(t *testing.T) {
+	p := NewParser("t", "")
+	snapshot := p.SnapshotDefaults()
+	p.Int("", "level", nil).Default(3)
+	p.RestoreDefaults(snapshot).RestoreDefaults(nil)
+	result, err := p.Parse([]string{})
+	if err != nil || result["level"] != 3 {
+		t.Errorf("Parse() = %v, %v, want the level default added after the snapshot, 3", result["level"], err)
+	}
+}
+
+func TestFileExistsDefault(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "deploy.enabled")
+	if err := os.WriteFile(present, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		path string
+		args []string
+		want bool
+	}{
+		{"file present", present, []string{}, true},
+		{"file absent", filepath.Join(dir, "missing"), []string{}, false},
+		{"flag given", filepath.Join(dir, "missing"), []string{"--deploy"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Bool("", "deploy", nil).DefaultFrom(FileExistsDefault(tt.path))
+			result, err := p.Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if result["deploy"] != tt.want {
+				t.Errorf("deploy = %v, want %v", result["deploy"], tt.want)
+			}
+		})
+	}
+}
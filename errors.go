@@ -0,0 +1,288 @@
+package argparse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrorKind classifies parse errors
+type ErrorKind int
+
+const (
+	// KindInvalidValue is a value that could not be converted
+	KindInvalidValue ErrorKind = iota
+	// KindMissingValue is a flag given without its value
+	KindMissingValue
+	// KindUnknownArgument is a flag that is not defined
+	KindUnknownArgument
+	// KindUnexpectedPositional is a positional with no slot to fill
+	KindUnexpectedPositional
+	// KindMissingRequired is a required argument that was not given
+	KindMissingRequired
+	// KindNotAllowed is a flag used where it is not permitted
+	KindNotAllowed
+	// KindInvalidDefinition is a mistake in how the parser was set up
+	KindInvalidDefinition
+	// KindValidation is a failed validator check
+	KindValidation
+	// KindTooManyArguments is a command line over the SetMaxArgs limit
+	KindTooManyArguments
+	// KindAmbiguousOption is an abbreviated long option matching several flags
+	KindAmbiguousOption
+)
+
+// ErrorVerbosity controls how much ParseOrExit prints with an error
+type ErrorVerbosity int
+
+const (
+	// ErrorHelp prints the error followed by the full help (the default)
+	ErrorHelp ErrorVerbosity = iota
+	// ErrorUsage prints the error followed by the usage line
+	ErrorUsage
+	// ErrorMessage prints only the error
+	ErrorMessage
+)
+
+// ParseError is the error returned by Parse for invalid command lines
+type ParseError struct {
+	Kind     ErrorKind
+	Argument string
+	Message  string
+	Err      error
+	// Position is the 1-based index of the offending token among the
+	// arguments, or 0 when the error is not about a single token
+	Position int
+}
+
+// Error returns the error message
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying cause, if any
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownArgumentError is the cause of a KindUnknownArgument ParseError
+type UnknownArgumentError struct {
+	Name string
+
+	format string // Messages.UnknownArgument of the parser
+}
+
+// Error returns the error message
+func (e *UnknownArgumentError) Error() string {
+	format := e.format
+	if format == "" {
+		format = DefaultMessages().UnknownArgument
+	}
+	return fmt.Sprintf(format, e.Name)
+}
+
+// MissingRequiredError is the cause of a KindMissingRequired ParseError
+type MissingRequiredError struct {
+	Name string
+
+	format string // Messages.RequiredMissing of the parser
+}
+
+// Error returns the error message
+func (e *MissingRequiredError) Error() string {
+	format := e.format
+	if format == "" {
+		format = DefaultMessages().RequiredMissing
+	}
+	return fmt.Sprintf(format, e.Name)
+}
+
+// InvalidValueError is the cause of a KindInvalidValue ParseError for a value
+// that could not be converted
+type InvalidValueError struct {
+	Name  string
+	Value string
+	Err   error
+
+	format string // Messages.InvalidValue of the parser
+}
+
+// Error returns the error message
+func (e *InvalidValueError) Error() string {
+	format := e.format
+	if format == "" {
+		format = DefaultMessages().InvalidValue
+	}
+	return fmt.Sprintf(format, e.Name, e.Err)
+}
+
+// Unwrap returns the conversion error
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidChoiceError is the cause of a KindInvalidValue ParseError for a
+// value outside the valid choices
+type InvalidChoiceError struct {
+	Name    string
+	Value   string
+	Choices []string
+
+	format string // Messages.InvalidChoice of the parser
+}
+
+// Error returns the error message
+func (e *InvalidChoiceError) Error() string {
+	format := e.format
+	if format == "" {
+		format = DefaultMessages().InvalidChoice
+	}
+	return fmt.Sprintf(format, e.Name, e.Value, strings.Join(e.Choices, ", "))
+}
+
+// with sets the cause of the error
+func (e *ParseError) with(cause error) *ParseError {
+	e.Err = cause
+	return e
+}
+
+// errorf builds a ParseError; an error among the format arguments becomes its cause
+func (p *Parser) errorf(kind ErrorKind, argument string, format string, a ...interface{}) *ParseError {
+	err := &ParseError{
+		Kind:     kind,
+		Argument: argument,
+		Message:  fmt.Sprintf(format, a...),
+	}
+	for _, v := range a {
+		if cause, ok := v.(error); ok {
+			err.Err = cause
+		}
+	}
+	return err
+}
+
+// atToken records that err is about the token at index, unless a position is
+// already known
+func atToken(err error, index int) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.Position == 0 && index >= 0 {
+		parseErr.Position = index + 1
+	}
+	return err
+}
+
+// shiftPosition makes the position of a subcommand's error relative to the
+// parent's arguments, offset being where the subcommand's arguments start
+func shiftPosition(err error, offset int) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.Position > 0 {
+		parseErr.Position += offset
+	}
+	return err
+}
+
+// validationError wraps a validator error as a ParseError
+func (p *Parser) validationError(err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+	return &ParseError{Kind: KindValidation, Message: err.Error(), Err: err}
+}
+
+// joinErrors combines several parse errors into one validation error that
+// unwraps to all of them
+func (p *Parser) joinErrors(errs []error) error {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return &ParseError{Kind: KindValidation, Message: strings.Join(messages, "; "), Err: errors.Join(errs...)}
+}
+
+// SetErrorVerbosity sets how much ParseOrExit prints along with an error
+func (p *Parser) SetErrorVerbosity(level ErrorVerbosity) *Parser {
+	p.errVerbose = level
+	return p
+}
+
+// GCCStyleErrors makes ParseOrExit print errors as compiler-style
+// diagnostics, "<args>:1:N: error: message", where N is the 1-based index of
+// the offending argument, so editors and linters can locate it
+func (p *Parser) GCCStyleErrors(enable bool) *Parser {
+	p.gccErrors = enable
+	return p
+}
+
+// FormatError returns the line ParseOrExit prints for err, without the
+// help or usage that may follow it
+func (p *Parser) FormatError(err error) string {
+	if !p.root().gccErrors {
+		return fmt.Sprintf(p.msgs().ErrorPrefix, err)
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.Position > 0 {
+		return fmt.Sprintf("%s:1:%d: error: %v", gccFileName, parseErr.Position, err)
+	}
+	return fmt.Sprintf("%s: error: %v", gccFileName, err)
+}
+
+// gccFileName stands in for the file name in GCC-style errors
+const gccFileName = "<args>"
+
+// printError reports a parse error according to the error verbosity
+func (p *Parser) printError(err error) {
+	w := p.errorOutput()
+	switch p.root().errVerbose {
+	case ErrorMessage:
+		fmt.Fprintf(w, "%s\n", p.FormatError(err))
+	case ErrorUsage:
+		fmt.Fprintf(w, "%s\n", p.FormatError(err))
+		p.writeUsage(w)
+		fmt.Fprintln(w)
+	default:
+		fmt.Fprintf(w, "%s\n\n", p.FormatError(err))
+		p.PrintHelpTo(w)
+	}
+}
+
+// SetExitCodes sets the exit code ParseOrExit uses for each kind of error.
+// Kinds without an entry exit with 1.
+func (p *Parser) SetExitCodes(codes map[ErrorKind]int) *Parser {
+	p.exitCodes = codes
+	return p
+}
+
+// SetHelpExitCode sets the exit code used after printing help when it is
+// requested. The default is 0.
+func (p *Parser) SetHelpExitCode(code int) *Parser {
+	p.helpExit = code
+	return p
+}
+
+// SetExitFunc replaces os.Exit, mainly so tests can observe exit codes
+func (p *Parser) SetExitFunc(exit func(code int)) *Parser {
+	p.exitFunc = exit
+	return p
+}
+
+// exitCode returns the configured exit code for an error
+func (p *Parser) exitCode(err error) int {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		if code, ok := p.root().exitCodes[parseErr.Kind]; ok {
+			return code
+		}
+	}
+	return 1
+}
+
+// exit terminates the program through the configured exit function
+func (p *Parser) exit(code int) {
+	if exit := p.root().exitFunc; exit != nil {
+		exit(code)
+		return
+	}
+	os.Exit(code)
+}
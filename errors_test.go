@@ -0,0 +1,323 @@
+package argparse
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHelpAndVersionErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want error
+	}{
+		{[]string{"-h"}, ErrHelpRequested},
+		{[]string{"-v", "--help"}, ErrHelpRequested},
+		{[]string{"--version"}, ErrVersionRequested},
+		{[]string{"-V", "-v"}, ErrVersionRequested},
+		{[]string{"sub", "--help"}, ErrHelpRequested},
+		{[]string{"-v"}, nil},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "").SetOutput(io.Discard)
+		p.AddHelp()
+		p.AddVersion()
+		p.Bool("v", "verbose", nil)
+		p.NewCommand("sub", "").Parser.AddHelp()
+		if _, err := p.Parse(tt.args); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.args, err, tt.want)
+		}
+	}
+}
+
+// parseOrExit runs ParseOrExit on args and returns the exit code, or -1 if
+// it did not exit. Output and errors are discarded unless p sets a writer.
+func parseOrExit(t *testing.T, p *Parser, args []string) int {
+	t.Helper()
+	saved := os.Args
+	t.Cleanup(func() { os.Args = saved })
+	os.Args = append([]string{"t"}, args...)
+
+	code := -1
+	if p.errOut == nil {
+		p.SetErrorOutput(io.Discard)
+	}
+	if p.out == nil {
+		p.SetOutput(io.Discard)
+	}
+	p.SetExitFunc(func(c int) { code = c })
+	p.ParseOrExit()
+	return code
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"success", []string{"--name", "x"}, -1},
+		{"mapped kind", []string{"--name", "x", "--bogus"}, 2},
+		{"another mapped kind", []string{}, 3},
+		{"unmapped kind", []string{"--name", "x", "--count", "many"}, 1},
+		{"help", []string{"--help"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetExitCodes(map[ErrorKind]int{
+				KindUnknownArgument: 2,
+				KindMissingRequired: 3,
+			})
+			p.AddHelp()
+			p.String("", "name", nil).Required()
+			p.Int("", "count", nil)
+			if got := parseOrExit(t, p, tt.args); got != tt.want {
+				t.Errorf("ParseOrExit(%q) exit code = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorVerbosity(t *testing.T) {
+	tests := []struct {
+		level   ErrorVerbosity
+		want    []string
+		notWant []string
+	}{
+		{ErrorHelp, []string{"Error: unknown argument: --bogus", "Usage: t", "Optional arguments:"}, nil},
+		{ErrorUsage, []string{"Error: unknown argument: --bogus", "Usage: t"}, []string{"Optional arguments:"}},
+		{ErrorMessage, []string{"Error: unknown argument: --bogus"}, []string{"Usage: t"}},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		p := NewParser("t", "").SetErrorVerbosity(tt.level).SetErrorOutput(&out)
+		p.String("", "name", nil)
+		parseOrExit(t, p, []string{"--bogus"})
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("verbosity %d: output is missing %q:\n%s", tt.level, want, out.String())
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(out.String(), notWant) {
+				t.Errorf("verbosity %d: output contains %q:\n%s", tt.level, notWant, out.String())
+			}
+		}
+	}
+}
+
+func TestErrorCauses(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantKind ErrorKind
+		check    func(err error) bool
+	}{
+		{"unknown argument", []string{"--name", "x", "--bogus"}, KindUnknownArgument, func(err error) bool {
+			var cause *UnknownArgumentError
+			return errors.As(err, &cause) && cause.Name == "--bogus"
+		}},
+		{"missing required", []string{}, KindMissingRequired, func(err error) bool {
+			var cause *MissingRequiredError
+			return errors.As(err, &cause) && cause.Name == "name"
+		}},
+		{"invalid value", []string{"--name", "x", "--count", "many"}, KindInvalidValue, func(err error) bool {
+			var cause *InvalidValueError
+			return errors.As(err, &cause) && cause.Value == "many" && cause.Err != nil
+		}},
+		{"invalid choice", []string{"--name", "x", "--format", "xml"}, KindInvalidValue, func(err error) bool {
+			var cause *InvalidChoiceError
+			return errors.As(err, &cause) && cause.Value == "xml" && len(cause.Choices) == 2
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("", "name", nil).Required()
+			p.Int("", "count", nil)
+			p.String("", "format", nil).Choices([]string{"json", "text"})
+			_, err := p.Parse(tt.args)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Kind != tt.wantKind {
+				t.Fatalf("Parse(%q) error = %v, want a ParseError of kind %d", tt.args, err, tt.wantKind)
+			}
+			if !tt.check(err) {
+				t.Errorf("Parse(%q) error = %v (cause %#v), want the typed cause", tt.args, err, parseErr.Err)
+			}
+		})
+	}
+}
+
+func TestErrorCausesTranslated(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		cause func(err error) error
+		want  string
+	}{
+		{"unknown argument", []string{"--name", "x", "--bogus"}, func(err error) error {
+			var cause *UnknownArgumentError
+			if errors.As(err, &cause) {
+				return cause
+			}
+			return nil
+		}, "argumento desconocido: --bogus"},
+		{"missing required", []string{}, func(err error) error {
+			var cause *MissingRequiredError
+			if errors.As(err, &cause) {
+				return cause
+			}
+			return nil
+		}, "falta el argumento obligatorio: name"},
+		{"invalid value", []string{"--name", "x", "--count", "many"}, func(err error) error {
+			var cause *InvalidValueError
+			if errors.As(err, &cause) {
+				return cause
+			}
+			return nil
+		}, "valor inválido para --count"},
+		{"invalid choice", []string{"--name", "x", "--format", "xml"}, func(err error) error {
+			var cause *InvalidChoiceError
+			if errors.As(err, &cause) {
+				return cause
+			}
+			return nil
+		}, `opción "xml" inválida para --format (elija entre json, text)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetMessages(Messages{
+				UnknownArgument: "argumento desconocido: %s",
+				RequiredMissing: "falta el argumento obligatorio: %s",
+				InvalidValue:    "valor inválido para %s: %v",
+				InvalidChoice:   "opción %[2]q inválida para %[1]s (elija entre %[3]s)",
+			})
+			p.String("", "name", nil).Required()
+			p.Int("", "count", nil)
+			p.String("", "format", nil).Choices([]string{"json", "text"})
+			_, err := p.Parse(tt.args)
+			cause := tt.cause(err)
+			if cause == nil {
+				t.Fatalf("Parse(%q) error = %v, want the typed cause", tt.args, err)
+			}
+			if got := cause.Error(); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("cause.Error() = %q, want the translated message %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNeverExits(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want error
+	}{
+		{"help", []string{"--help"}, ErrHelpRequested},
+		{"version", []string{"--version"}, ErrVersionRequested},
+		{"invalid", []string{"--bogus"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut strings.Builder
+			exited := false
+			p := NewParser("t", "").SetOutput(&out).SetErrorOutput(&errOut).SetExitFunc(func(int) { exited = true })
+			p.AddHelp()
+			p.AddVersion()
+			_, err := p.Parse(tt.args)
+			if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.args, err, tt.want)
+			}
+			if exited || out.Len() > 0 || errOut.Len() > 0 {
+				t.Errorf("Parse(%q) exited = %v, printed %q and %q; want no side effects", tt.args, exited, out.String(), errOut.String())
+			}
+		})
+	}
+}
+
+func TestOutputWriters(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantOut    string
+		wantErrOut string
+	}{
+		{"help", []string{"--help"}, "Usage: t", ""},
+		{"version", []string{"--version"}, "1.2.3", ""},
+		{"error", []string{"--bogus"}, "", "unknown argument"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut strings.Builder
+			p := NewParser("t", "").SetVersion("1.2.3").SetOutput(&out).SetErrorOutput(&errOut)
+			p.AddHelp()
+			p.AddVersion()
+			parseOrExit(t, p, tt.args)
+			for _, w := range []struct {
+				name, got, want string
+			}{{"output", out.String(), tt.wantOut}, {"error output", errOut.String(), tt.wantErrOut}} {
+				if w.want == "" && w.got != "" || !strings.Contains(w.got, w.want) {
+					t.Errorf("%s = %q, want %q", w.name, w.got, w.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHelpExitCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(p *Parser)
+		args  []string
+		want  int
+	}{
+		{"default", func(p *Parser) {}, []string{"--help"}, 0},
+		{"configured", func(p *Parser) { p.SetHelpExitCode(64) }, []string{"--help"}, 64},
+		{"subcommand help", func(p *Parser) { p.SetHelpExitCode(64) }, []string{"sub", "--help"}, 64},
+		{"errors unaffected", func(p *Parser) { p.SetHelpExitCode(64) }, []string{"--bogus"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.AddHelp()
+			p.NewCommand("sub", "").Parser.AddHelp()
+			tt.setup(p)
+			if got := parseOrExit(t, p, tt.args); got != tt.want {
+				t.Errorf("ParseOrExit(%q) exit code = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCCStyleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		gcc  bool
+		args []string
+		want string
+	}{
+		{"unknown flag", true, []string{"--name", "x", "--bogus"}, "<args>:1:3: error: unknown argument: --bogus"},
+		{"invalid value", true, []string{"--count", "many", "--name", "x"}, "<args>:1:2: error: "},
+		{"attached value", true, []string{"--name", "x", "--count=many"}, "<args>:1:3: error: "},
+		{"subcommand token", true, []string{"--name", "x", "sub", "--bogus"}, "<args>:1:4: error: unknown argument: --bogus"},
+		{"no single token", true, []string{}, "<args>: error: "},
+		{"disabled", false, []string{"--name", "x", "--bogus"}, "Error: unknown argument: --bogus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").GCCStyleErrors(tt.gcc)
+			p.String("", "name", nil).Required()
+			p.Int("", "count", nil)
+			p.NewCommand("sub", "")
+			_, err := p.Parse(tt.args)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded", tt.args)
+			}
+			if got := p.FormatError(err); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("FormatError = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
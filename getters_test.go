@@ -0,0 +1,98 @@
+package argparse
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestListGetters(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		arg  string
+		want []string
+	}{
+		{"list", []string{"--tags", "a,b"}, "tags", []string{"a", "b"}},
+		{"append string", []string{"--name", "x", "--name", "y"}, "name", []string{"x", "y"}},
+		{"variadic positional", []string{"f", "g"}, "files", []string{"f", "g"}},
+		{"unset", []string{}, "tags", []string{}},
+		{"not a list", []string{"--level", "3"}, "level", []string{}},
+		{"unknown", []string{}, "missing", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.List("", "tags", nil)
+			p.String("", "name", nil).Append()
+			p.Int("", "level", nil)
+			p.Positional("files", nil).Variadic()
+			if _, err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			list, slice := p.GetList(tt.arg), p.GetStringSlice(tt.arg)
+			if !reflect.DeepEqual(list, tt.want) || !reflect.DeepEqual(slice, tt.want) {
+				t.Errorf("GetList(%q) = %q, GetStringSlice = %q, want %q", tt.arg, list, slice, tt.want)
+			}
+		})
+	}
+}
+
+func TestGettersParseOnce(t *testing.T) {
+	saved := os.Args
+	defer func() { os.Args = saved }()
+	os.Args = []string{"t", "--name", "x"}
+
+	hooks := 0
+	p := NewParser("t", "")
+	p.String("", "name", nil)
+	p.AddPreParseHook(func(args []string) ([]string, error) {
+		hooks++
+		return args, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if got := p.GetString("name"); got != "x" {
+			t.Errorf("GetString(name) = %q, want %q", got, "x")
+		}
+	}
+	if hooks != 1 {
+		t.Errorf("getters parsed %d times, want once", hooks)
+	}
+
+	if _, err := p.Parse([]string{"--name", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.GetString("name"); got != "y" || hooks != 2 {
+		t.Errorf("after Parse: name = %q after %d parses, want %q after 2", got, hooks, "y")
+	}
+}
+
+func TestGetListString(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		arg  string
+		want string
+	}{
+		{"default separator", []string{"--tags", "a,b,c"}, "tags", "a,b,c"},
+		{"custom separator", []string{"--path", "/bin:/usr/bin"}, "path", "/bin:/usr/bin"},
+		{"append", []string{"--name", "x", "--name", "y"}, "name", "x,y"},
+		{"single value", []string{"--tags", "a"}, "tags", "a"},
+		{"unset", []string{}, "path", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.List("", "tags", nil)
+			p.List("", "path", nil).Sep(":")
+			p.String("", "name", nil).Append()
+			if _, err := p.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.GetListString(tt.arg); got != tt.want {
+				t.Errorf("GetListString(%q) = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
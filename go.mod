@@ -1,3 +1,7 @@
 module github.com/bunnyhawper/argparse-go
 
 go 1.24.2
+
+require golang.org/x/term v0.40.0
+
+require golang.org/x/sys v0.41.0 // indirect
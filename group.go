@@ -0,0 +1,137 @@
+package argparse
+
+import "strings"
+
+// Group constrains how many of its member flags may be given together
+type Group struct {
+	parser  *Parser
+	members []*Argument
+	min     int
+	max     int
+}
+
+// NewExactlyOneGroup creates a group of flags of which exactly one must be
+// given. Add members with Add; usage shows them as (-a | -b | -c).
+func (p *Parser) NewExactlyOneGroup() *Group {
+	group := &Group{parser: p, min: 1, max: 1}
+	p.groups = append(p.groups, group)
+	return group
+}
+
+// NewCountRangeGroup creates a group of flags of which at least min and at
+// most max must be given
+func (p *Parser) NewCountRangeGroup(min, max int) *Group {
+	group := &Group{parser: p, min: min, max: max}
+	p.groups = append(p.groups, group)
+	return group
+}
+
+// exactlyOne reports whether exactly one member must be given
+func (g *Group) exactlyOne() bool {
+	return g.min == 1 && g.max == 1
+}
+
+// Add adds flags to the group
+func (g *Group) Add(args ...*Argument) *Group {
+	g.members = append(g.members, args...)
+	return g
+}
+
+// labels returns the usage label of each member
+func (g *Group) labels() []string {
+	labels := make([]string, len(g.members))
+	for i, arg := range g.members {
+		if arg.ShortName != "" {
+			labels[i] = "-" + arg.ShortName
+		} else {
+			labels[i] = "--" + arg.Name
+		}
+		if arg.ArgType != Bool && arg.ArgType != Counter {
+			labels[i] += " " + g.parser.metavar(arg)
+		}
+	}
+	return labels
+}
+
+// check reports a group whose number of given members is out of range
+func (g *Group) check() error {
+	count := 0
+	for _, arg := range g.members {
+		if arg.isSet {
+			count++
+		}
+	}
+	if count >= g.min && count <= g.max {
+		return nil
+	}
+
+	names := make([]string, len(g.members))
+	for i, arg := range g.members {
+		if arg.Name != "" {
+			names[i] = "--" + arg.Name
+		} else {
+			names[i] = "-" + arg.ShortName
+		}
+	}
+	if g.exactlyOne() {
+		return g.parser.errorf(KindValidation, "", g.parser.msgs().ExactlyOne, strings.Join(names, ", "), count)
+	}
+	return g.parser.errorf(KindValidation, "", g.parser.msgs().CountRange, g.min, g.max, strings.Join(names, ", "), count)
+}
+
+// requirement is a RequireTogether or RequireOneOf rule over named arguments
+type requirement struct {
+	names []string
+	all   bool
+}
+
+// RequireTogether makes the named arguments all-or-nothing: giving some
+// but not all of them is an error
+func (p *Parser) RequireTogether(names ...string) *Parser {
+	p.requires = append(p.requires, requirement{names: names, all: true})
+	return p
+}
+
+// RequireOneOf requires at least one of the named arguments
+func (p *Parser) RequireOneOf(names ...string) *Parser {
+	p.requires = append(p.requires, requirement{names: names})
+	return p
+}
+
+// checkRequirement reports a requirement the parsed arguments do not meet
+func (p *Parser) checkRequirement(req requirement, result map[string]interface{}) error {
+	msgs := p.msgs()
+	labels := make([]string, len(req.names))
+	missing := make([]string, 0, len(req.names))
+	for i, name := range req.names {
+		arg := p.findRequired(name)
+		if arg == nil {
+			return p.errorf(KindInvalidDefinition, name, msgs.UnknownRequirement, name)
+		}
+		labels[i] = name
+		if !arg.isPositional {
+			labels[i] = "--" + name
+		}
+		if p.missing(arg, result) {
+			missing = append(missing, labels[i])
+		}
+	}
+
+	switch {
+	case req.all && len(missing) > 0 && len(missing) < len(req.names):
+		return p.errorf(KindMissingRequired, missing[0], msgs.RequireTogether, strings.Join(labels, ", "), strings.Join(missing, ", ")).with(&MissingRequiredError{Name: strings.TrimPrefix(missing[0], "--"), format: msgs.RequiredMissing})
+	case !req.all && len(req.names) > 0 && len(missing) == len(req.names):
+		return p.errorf(KindMissingRequired, "", msgs.RequireOneOf, strings.Join(labels, ", ")).with(&MissingRequiredError{Name: req.names[0], format: msgs.RequiredMissing})
+	}
+	return nil
+}
+
+// findRequired returns the flag or positional named in a requirement
+func (p *Parser) findRequired(name string) *Argument {
+	for _, arg := range append(p.options(), p.positional...) {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package argparse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExactlyOneGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"none given", []string{}, "exactly one of --json, -t is required (0 given)"},
+		{"one given", []string{"--json"}, ""},
+		{"other given", []string{"-t"}, ""},
+		{"both given", []string{"--json", "-t"}, "exactly one of --json, -t is required (2 given)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.NewExactlyOneGroup().Add(p.Bool("", "json", nil), p.Bool("t", "", nil))
+			_, err := p.Parse(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", tt.args, err)
+				}
+				return
+			}
+			parseErr, ok := err.(*ParseError)
+			if !ok || parseErr.Kind != KindValidation || parseErr.Message != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, want validation error %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExactlyOneGroupUsage(t *testing.T) {
+	p := NewParser("t", "").SetHelpWidth(80)
+	p.NewExactlyOneGroup().Add(p.Bool("", "json", nil), p.String("f", "format", nil))
+	help := p.HelpString()
+	if want := "(--json | -f FORMAT)"; !strings.Contains(help, want) {
+		t.Errorf("help = %q, want usage to contain %q", help, want)
+	}
+}
+
+func TestCountRangeGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"below min", []string{"-a"}, "between 2 and 3 of -a, --b, --c, --d are required (1 given)"},
+		{"none", []string{}, "between 2 and 3 of -a, --b, --c, --d are required (0 given)"},
+		{"at min", []string{"-a", "--b"}, ""},
+		{"at max", []string{"-a", "--b", "--c", "x"}, ""},
+		{"above max", []string{"-a", "--b", "--c", "x", "--d"}, "between 2 and 3 of -a, --b, --c, --d are required (4 given)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.NewCountRangeGroup(2, 3).Add(p.Bool("a", "", nil), p.Bool("", "b", nil), p.String("", "c", nil), p.Counter("", "d", nil))
+			_, err := p.Parse(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", tt.args, err)
+				}
+				return
+			}
+			parseErr, ok := err.(*ParseError)
+			if !ok || parseErr.Kind != KindValidation || parseErr.Message != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, want validation error %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		wantErr string
+	}{
+		{"together none", []string{"--token", "t"}, "", ""},
+		{"together all", []string{"--username", "u", "--password", "p", "--token", "t"}, "", ""},
+		{"together partial", []string{"--username", "u"}, "", "--username, --password must be given together (missing --password)"},
+		{"together from environment", []string{"--username", "u", "--token", "t"}, "p", ""},
+		{"one of flag", []string{"--token", "t"}, "", ""},
+		{"one of positional", []string{"file"}, "", ""},
+		{"one of none", []string{}, "", "at least one of --token, input is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("TEST_PASSWORD", tt.env)
+			}
+			p := NewParser("t", "")
+			p.String("", "username", nil)
+			p.String("", "password", nil).Env("TEST_PASSWORD")
+			p.String("", "token", nil)
+			p.Positional("input", nil)
+			p.RequireTogether("username", "password").RequireOneOf("token", "input")
+			_, err := p.Parse(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", tt.args, err)
+				}
+				return
+			}
+			var cause *MissingRequiredError
+			parseErr, ok := err.(*ParseError)
+			if !ok || parseErr.Kind != KindMissingRequired || parseErr.Message != tt.wantErr || !errors.As(err, &cause) {
+				t.Errorf("Parse(%q) error = %v, want missing required error %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
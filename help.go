@@ -0,0 +1,510 @@
+package argparse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// compactHelpWidth is the width below which help switches to the compact layout
+	compactHelpWidth = 60
+	// defaultHelpWidth is used when the terminal width cannot be determined
+	defaultHelpWidth = 80
+	// maxHelpLabelWidth caps the label column; longer labels get their own line
+	maxHelpLabelWidth = 30
+	// defaultMaxInlineChoices is the longest choice list shown in place of a metavar
+	defaultMaxInlineChoices = 5
+)
+
+// helpRow is one entry of a help section
+type helpRow struct {
+	label       string
+	description string
+}
+
+// helpSection is a titled group of help rows
+type helpSection struct {
+	title string
+	rows  []helpRow
+}
+
+// CompactHelp prints each argument on its own line with the description
+// indented beneath, which reads better on narrow terminals
+func (p *Parser) CompactHelp(enable bool) *Parser {
+	p.compactHelp = enable
+	return p
+}
+
+// SetHelpWidth sets a fixed width for help output instead of the terminal
+// width. Widths below 60 columns switch to the compact layout.
+func (p *Parser) SetHelpWidth(width int) *Parser {
+	p.helpWidth = width
+	return p
+}
+
+// SetWidthFunc sets the function queried for the terminal width each time
+// help is printed. A result of zero or less falls back to 80 columns.
+func (p *Parser) SetWidthFunc(width func() int) *Parser {
+	p.widthFunc = width
+	return p
+}
+
+// width returns the width help is laid out for: a fixed width if set,
+// otherwise the width of the terminal the parser's output goes to at print
+// time, falling back to $COLUMNS and 80
+func (p *Parser) width() int {
+	root := p.root()
+	for _, parser := range []*Parser{p, root} {
+		if parser.helpWidth > 0 {
+			return parser.helpWidth
+		}
+	}
+	for _, parser := range []*Parser{p, root} {
+		if parser.widthFunc != nil {
+			if width := parser.widthFunc(); width > 0 {
+				return width
+			}
+			return defaultHelpWidth
+		}
+	}
+	if width, ok := terminalSize(p.output()); ok {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultHelpWidth
+}
+
+// useCompactHelp reports whether help should use the single-column layout
+func (p *Parser) useCompactHelp() bool {
+	if p.compactHelp || p.root().compactHelp {
+		return true
+	}
+	return p.width() < compactHelpWidth
+}
+
+// SetOutput sets where help, version and choice listings are written.
+// The default is os.Stdout.
+func (p *Parser) SetOutput(w io.Writer) *Parser {
+	p.out = w
+	return p
+}
+
+// SetErrorOutput sets where ParseOrExit writes errors and where warnings go.
+// The default is os.Stderr.
+func (p *Parser) SetErrorOutput(w io.Writer) *Parser {
+	p.errOut = w
+	return p
+}
+
+// ShowDefaults controls whether help appends "(default: X)" to arguments
+// with a default other than a zero value. It is enabled by default.
+func (p *Parser) ShowDefaults(enable bool) *Parser {
+	p.noDefaults = !enable
+	return p
+}
+
+// ShowSeeAlso controls whether a subcommand's help ends with a "See also"
+// list of its sibling commands. It is disabled by default.
+func (p *Parser) ShowSeeAlso(enable bool) *Parser {
+	p.seeAlso = enable
+	return p
+}
+
+// describe returns the help description of an argument, with its default
+// appended unless that is disabled, a zero value, sensitive or already
+// marked among the choices
+func (p *Parser) describe(arg *Argument) string {
+	if p.noDefaults || p.root().noDefaults || arg.IsRequired || arg.IsSensitive || arg.DefaultVal == nil || p.marksDefault(arg) {
+		return arg.Description
+	}
+	if v := reflect.ValueOf(arg.DefaultVal); v.IsZero() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return arg.Description
+	}
+
+	var text string
+	switch v := arg.DefaultVal.(type) {
+	case []string:
+		text = strings.Join(v, arg.separator())
+	case time.Duration:
+		if arg.ArgType == TimeOfDay {
+			text = formatTimeOfDay(v)
+		} else {
+			text = v.String()
+		}
+	default:
+		text = formatValue(v)
+	}
+
+	suffix := fmt.Sprintf(p.msgs().DefaultSuffix, text)
+	if arg.Description == "" {
+		return suffix
+	}
+	return arg.Description + " " + suffix
+}
+
+// output returns the writer for help and version output
+func (p *Parser) output() io.Writer {
+	for _, parser := range []*Parser{p, p.root()} {
+		if parser.out != nil {
+			return parser.out
+		}
+	}
+	return os.Stdout
+}
+
+// errorOutput returns the writer for errors and warnings
+func (p *Parser) errorOutput() io.Writer {
+	for _, parser := range []*Parser{p, p.root()} {
+		if parser.errOut != nil {
+			return parser.errOut
+		}
+	}
+	return os.Stderr
+}
+
+// PrintHelp prints the help message to the parser's output
+func (p *Parser) PrintHelp() {
+	p.PrintHelpTo(p.output())
+}
+
+// HelpString returns the help message PrintHelp would print
+func (p *Parser) HelpString() string {
+	var b strings.Builder
+	p.PrintHelpTo(&b)
+	return b.String()
+}
+
+// PrintHelpTo writes the help message to w
+func (p *Parser) PrintHelpTo(w io.Writer) {
+	msgs := p.msgs()
+	p.writeUsage(w)
+	fmt.Fprintf(w, "\n\n%s\n\n", p.description)
+
+	sections := make([]helpSection, 0, 3)
+
+	if len(p.positional) > 0 {
+		rows := make([]helpRow, 0, len(p.positional))
+		for _, pos := range p.positional {
+			rows = append(rows, helpRow{pos.Name, p.describe(pos)})
+		}
+		sections = append(sections, helpSection{msgs.PositionalHeader, rows})
+	}
+
+	if len(p.args) > 0 {
+		// Labels are built together so grouped flags align with the rest
+		labels := p.flagLabels(p.args)
+		grouped := make(map[*Argument]bool)
+		for _, group := range p.argGroups {
+			for _, arg := range group.args {
+				grouped[arg] = true
+			}
+		}
+
+		rows := make([]helpRow, 0, len(p.args))
+		groupRows := make(map[*Argument]helpRow)
+		for i, arg := range p.args {
+			row := helpRow{labels[i], p.describe(arg)}
+			if grouped[arg] {
+				groupRows[arg] = row
+			} else {
+				rows = append(rows, row)
+			}
+		}
+		if len(rows) > 0 {
+			sections = append(sections, helpSection{msgs.OptionalHeader, rows})
+		}
+
+		for _, group := range p.argGroups {
+			rows := make([]helpRow, 0, len(group.args))
+			for _, arg := range group.args {
+				rows = append(rows, groupRows[arg])
+			}
+			if len(rows) > 0 {
+				sections = append(sections, helpSection{group.heading(), rows})
+			}
+		}
+	}
+
+	if len(p.subparsers) > 0 {
+		rows := make([]helpRow, 0, len(p.subparsers))
+		for _, name := range p.commands {
+			rows = append(rows, helpRow{name, p.subparsers[name].description})
+		}
+		sections = append(sections, helpSection{msgs.CommandsHeader, rows})
+	}
+
+	if p.parent != nil && (p.seeAlso || p.root().seeAlso) {
+		rows := make([]helpRow, 0, len(p.parent.commands))
+		for _, name := range p.parent.commands {
+			if sibling := p.parent.subparsers[name]; sibling != p {
+				rows = append(rows, helpRow{name, sibling.description})
+			}
+		}
+		if len(rows) > 0 {
+			sections = append(sections, helpSection{msgs.SeeAlsoHeader, rows})
+		}
+	}
+
+	// Align every description to the longest label, up to a cap
+	labelWidth := 0
+	for _, section := range sections {
+		for _, row := range section.rows {
+			if n := utf8.RuneCountInString(row.label); n > labelWidth {
+				labelWidth = n
+			}
+		}
+	}
+	if labelWidth > maxHelpLabelWidth {
+		labelWidth = maxHelpLabelWidth
+	}
+
+	for _, section := range sections {
+		p.printHelpSection(w, section, labelWidth)
+	}
+
+	if p.epilog != "" {
+		fmt.Fprintf(w, "%s\n", p.epilog)
+	}
+}
+
+// writeUsage writes the usage line without a trailing newline
+func (p *Parser) writeUsage(w io.Writer) {
+	msgs := p.msgs()
+	fmt.Fprintf(w, msgs.Usage, p.name)
+
+	if len(p.args) > 0 {
+		fmt.Fprintf(w, "%s", msgs.UsageOptions)
+	}
+
+	for _, arg := range p.args {
+		if label := p.usageChoices(arg); label != "" {
+			fmt.Fprintf(w, " %s", label)
+		}
+	}
+
+	for _, group := range p.groups {
+		if group.exactlyOne() {
+			fmt.Fprintf(w, " (%s)", strings.Join(group.labels(), " | "))
+		}
+	}
+
+	for _, pos := range p.positional {
+		metavar := p.metavar(pos)
+		if pos.FixedCount > 0 {
+			fmt.Fprintf(w, " %s", strings.TrimSpace(strings.Repeat(metavar+" ", pos.FixedCount)))
+		} else if (pos.IsRemainder || pos.IsVariadic) && pos.IsRequired {
+			fmt.Fprintf(w, " %s...", metavar)
+		} else if pos.IsRemainder || pos.IsVariadic {
+			fmt.Fprintf(w, " [%s...]", metavar)
+		} else if pos.IsRequired {
+			fmt.Fprintf(w, " %s", metavar)
+		} else {
+			fmt.Fprintf(w, " [%s]", metavar)
+		}
+	}
+
+	if len(p.commands) > 0 {
+		fmt.Fprintf(w, " {%s}", strings.Join(p.commands, ","))
+	}
+}
+
+// usageChoices returns the usage label of a flag whose choices are shown
+// inline, like [--sort {date|title}], or "" for other flags and for members
+// of exactly-one groups, which the group's own label shows
+func (p *Parser) usageChoices(arg *Argument) string {
+	if arg.ArgType == Bool || arg.ArgType == Counter || arg.Metavar != "" {
+		return ""
+	}
+	if arg.choiceRange == nil && p.inlineChoices(arg) == nil {
+		return ""
+	}
+	for _, group := range p.groups {
+		for _, member := range group.members {
+			if group.exactlyOne() && member == arg {
+				return ""
+			}
+		}
+	}
+	flag := "--" + arg.Name
+	if arg.Name == "" {
+		flag = "-" + arg.ShortName
+	}
+	if arg.IsRequired {
+		return flag + " " + p.metavar(arg)
+	}
+	return "[" + flag + " " + p.metavar(arg) + "]"
+}
+
+// SetMaxInlineChoices sets how many choices are shown inline as {a|b|c} in
+// place of the metavar; longer choice lists use the metavar. The default is 5.
+func (p *Parser) SetMaxInlineChoices(n int) *Parser {
+	p.maxChoices = n
+	return p
+}
+
+// metavar returns the placeholder shown for an argument's value: the explicit
+// Metavar, a short choice list with the default marked, or the upper-cased name
+func (p *Parser) metavar(arg *Argument) string {
+	if arg.Metavar != "" {
+		return arg.Metavar
+	}
+	if arg.choiceRange != nil {
+		return "{" + arg.rangeLabel() + "}"
+	}
+	if valid := p.inlineChoices(arg); valid != nil {
+		// The default choice is marked with "*"
+		choices := make([]string, len(valid))
+		for i, choice := range valid {
+			choices[i] = choice
+			if arg.DefaultVal != nil && choice == fmt.Sprint(arg.DefaultVal) {
+				choices[i] += "*"
+			}
+		}
+		return "{" + strings.Join(choices, "|") + "}"
+	}
+	if arg.isPositional {
+		return arg.Name
+	}
+	name := arg.Name
+	if name == "" {
+		name = arg.ShortName
+	}
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// inlineChoices returns the choices short enough to show in place of the
+// metavar, or nil
+func (p *Parser) inlineChoices(arg *Argument) []string {
+	max := p.root().maxChoices
+	if max == 0 {
+		max = defaultMaxInlineChoices
+	}
+	if valid := arg.choices(); len(valid) > 0 && len(valid) <= max {
+		return valid
+	}
+	return nil
+}
+
+// marksDefault reports whether the metavar of a flag already shows its
+// default as the choice marked with "*"
+func (p *Parser) marksDefault(arg *Argument) bool {
+	if arg.isPositional || arg.ArgType == Bool || arg.ArgType == Counter || arg.Metavar != "" || arg.choiceRange != nil || arg.DefaultVal == nil {
+		return false
+	}
+	return containsString(p.inlineChoices(arg), fmt.Sprint(arg.DefaultVal))
+}
+
+// flagLabels returns the help labels for flags, padding the short-name part
+// so every long name starts in the same column
+func (p *Parser) flagLabels(args []*Argument) []string {
+	shortWidth := 0
+	for _, arg := range args {
+		if arg.ShortName != "" {
+			if n := utf8.RuneCountInString(arg.ShortName) + 3; n > shortWidth {
+				shortWidth = n
+			}
+		}
+	}
+
+	labels := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case arg.ShortName != "" && arg.Name != "":
+			short := "-" + arg.ShortName + ", "
+			padding := shortWidth - utf8.RuneCountInString(short)
+			labels[i] = short + strings.Repeat(" ", padding) + p.longLabel(arg)
+		case arg.ShortName != "":
+			labels[i] = "-" + arg.ShortName
+		default:
+			labels[i] = strings.Repeat(" ", shortWidth) + p.longLabel(arg)
+		}
+		if arg.ArgType != Bool && arg.ArgType != Counter {
+			labels[i] += " " + p.metavar(arg)
+		}
+	}
+	return labels
+}
+
+// longLabel returns the long form of a flag for help, --[no-]NAME for Bools
+// whose negated form is not taken by another flag
+func (p *Parser) longLabel(arg *Argument) string {
+	if arg.negatable() && p.findOption(func(option *Argument) bool { return option.Name == "no-"+arg.Name }) == nil {
+		return "--[no-]" + arg.Name
+	}
+	return "--" + arg.Name
+}
+
+// printHelpSection prints a titled list of rows in the two-column or compact
+// layout. Labels wider than labelWidth get the description on the next line.
+func (p *Parser) printHelpSection(w io.Writer, section helpSection, labelWidth int) {
+	fmt.Fprintf(w, "%s\n", section.title)
+	compact := p.useCompactHelp()
+	width := p.width()
+	for _, row := range section.rows {
+		switch {
+		case compact:
+			fmt.Fprintf(w, "  %s\n", row.label)
+			for _, line := range wrapText(row.description, width-6) {
+				fmt.Fprintf(w, "      %s\n", line)
+			}
+		case row.description == "":
+			fmt.Fprintf(w, "  %s\n", row.label)
+		default:
+			lines := wrapText(row.description, width-labelWidth-4)
+			if len(lines) == 0 {
+				lines = []string{""}
+			}
+			if utf8.RuneCountInString(row.label) > labelWidth {
+				fmt.Fprintf(w, "  %s\n", row.label)
+			} else {
+				fmt.Fprintf(w, "  %-*s  %s\n", labelWidth, row.label, lines[0])
+				lines = lines[1:]
+			}
+			// Continuation lines line up under the description column
+			for _, line := range lines {
+				fmt.Fprintf(w, "  %*s  %s\n", labelWidth, "", line)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// minWrapWidth is the narrowest column descriptions are wrapped to
+const minWrapWidth = 20
+
+// wrapText splits text into lines of at most width columns at spaces. Words
+// longer than a line are kept whole. Empty text gives no lines.
+func wrapText(text string, width int) []string {
+	if width < minWrapWidth {
+		width = minWrapWidth
+	}
+	lines := make([]string, 0, 1)
+	for _, paragraph := range strings.Split(text, "\n") {
+		line, lineWidth := "", 0
+		for _, word := range strings.Fields(paragraph) {
+			n := utf8.RuneCountInString(word)
+			if lineWidth > 0 && lineWidth+1+n > width {
+				lines = append(lines, line)
+				line, lineWidth = "", 0
+			}
+			if lineWidth > 0 {
+				line += " "
+				lineWidth++
+			}
+			line += word
+			lineWidth += n
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,420 @@
+package argparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		define  func(p *Parser)
+		want    string
+		notWant string
+	}{
+		{
+			"plain default",
+			func(p *Parser) { p.Int("", "limit", &Argument{Description: "Limit"}).Default(10) },
+			"Limit (default: 10)",
+			"",
+		},
+		{
+			"default marked among choices",
+			func(p *Parser) {
+				p.String("", "sort", &Argument{Description: "Sort order", ValidChoices: []string{"priority", "date", "title"}}).Default("date")
+			},
+			"--sort {priority|date*|title}  Sort order",
+			"(default: date)",
+		},
+		{
+			"default outside the inline choices",
+			func(p *Parser) {
+				p.String("", "sort", &Argument{Description: "Sort order", ValidChoices: []string{"a", "b", "c", "d", "e", "f"}}).Default("b")
+			},
+			"Sort order (default: b)",
+			"",
+		},
+		{
+			"positional with choices",
+			func(p *Parser) {
+				p.Positional("mode", &Argument{Description: "Mode", ValidChoices: []string{"fast", "slow"}}).Default("fast")
+			},
+			"Mode (default: fast)",
+			"",
+		},
+		{
+			"zero default",
+			func(p *Parser) { p.Int("", "limit", &Argument{Description: "Limit"}) },
+			"Limit",
+			"(default:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetHelpWidth(100)
+			tt.define(p)
+			help := p.HelpString()
+			if !strings.Contains(help, tt.want) {
+				t.Errorf("help = %q, want it to contain %q", help, tt.want)
+			}
+			if tt.notWant != "" && strings.Contains(help, tt.notWant) {
+				t.Errorf("help = %q, want it not to contain %q", help, tt.notWant)
+			}
+		})
+	}
+}
+
+func TestHelpWidthFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns string
+		want    int
+	}{
+		{"columns", "100", 100},
+		{"no columns", "", defaultHelpWidth},
+		{"invalid columns", "wide", defaultHelpWidth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COLUMNS", tt.columns)
+			p := NewParser("t", "").SetOutput(&strings.Builder{})
+			if got := p.width(); got != tt.want {
+				t.Errorf("width() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompactHelp(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(p *Parser)
+		want    string
+		notWant string
+	}{
+		{"wide", func(p *Parser) { p.SetHelpWidth(80) }, "  -o, --output OUTPUT  Output file\n", "\n      Output file"},
+		{"compact", func(p *Parser) { p.SetHelpWidth(80).CompactHelp(true) }, "  -o, --output OUTPUT\n      Output file\n", ""},
+		{"narrow", func(p *Parser) { p.SetHelpWidth(50) }, "  -o, --output OUTPUT\n      Output file\n", ""},
+		{"narrow width func", func(p *Parser) { p.SetWidthFunc(func() int { return 40 }) }, "  -o, --output OUTPUT\n      Output file\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("o", "output", &Argument{Description: "Output file"})
+			tt.setup(p)
+			help := p.HelpString()
+			if !strings.Contains(help, tt.want) {
+				t.Errorf("help is missing %q:\n%s", tt.want, help)
+			}
+			if tt.notWant != "" && strings.Contains(help, tt.notWant) {
+				t.Errorf("help contains %q:\n%s", tt.notWant, help)
+			}
+		})
+	}
+}
+
+// descriptionColumn returns the column where text starts in help, -1 if absent
+func descriptionColumn(help, text string) int {
+	for _, line := range strings.Split(help, "\n") {
+		if i := strings.Index(line, text); i >= 0 {
+			return len([]rune(line[:i]))
+		}
+	}
+	return -1
+}
+
+func TestHelpAlignment(t *testing.T) {
+	tests := []struct {
+		name      string
+		long      string
+		wantWrap  bool
+		wantWidth int
+	}{
+		{"short labels", "out", false, len("  -o, --out OUT  ")},
+		{"longer label", "output", false, len("  -o, --output OUTPUT  ")},
+		{"capped label", "output-directory-for-all-files", true, maxHelpLabelWidth + 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetHelpWidth(120)
+			p.String("o", tt.long, &Argument{Description: "Target"})
+			p.Bool("", "x", &Argument{Description: "Extra"})
+			p.Positional("file", &Argument{Description: "Input"})
+			p.NewCommand("add", "Add it")
+			help := p.HelpString()
+
+			for _, text := range []string{"Extra", "Input", "Add it"} {
+				if got := descriptionColumn(help, text); got != tt.wantWidth {
+					t.Errorf("%q starts at column %d, want %d:\n%s", text, got, tt.wantWidth, help)
+				}
+			}
+			wrapped := strings.Contains(help, "--"+tt.long+" "+strings.ToUpper(strings.ReplaceAll(tt.long, "-", "_"))+"\n")
+			if wrapped != tt.wantWrap {
+				t.Errorf("label on its own line = %v, want %v:\n%s", wrapped, tt.wantWrap, help)
+			}
+		})
+	}
+}
+
+func TestHelpString(t *testing.T) {
+	p := NewParser("t", "A tool").SetHelpWidth(80)
+	p.AddHelp()
+	sub := p.NewCommand("add", "Add an item").Parser
+	sub.String("", "title", &Argument{Description: "Item title"})
+
+	tests := []struct {
+		name   string
+		parser *Parser
+		want   []string
+	}{
+		{"root", p, []string{"Usage: t", "A tool", "-h, --help", "add"}},
+		{"subcommand", sub, []string{"Usage: add", "Item title"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var printed strings.Builder
+			tt.parser.SetOutput(&printed)
+			tt.parser.PrintHelp()
+			help := tt.parser.HelpString()
+			if help != printed.String() {
+				t.Errorf("HelpString() = %q, PrintHelp printed %q", help, printed.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(help, want) {
+					t.Errorf("HelpString() is missing %q:\n%s", want, help)
+				}
+			}
+		})
+	}
+}
+
+func TestHelpLongNameAlignment(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(p *Parser)
+		want   []string
+	}{
+		{"mixed", func(p *Parser) {
+			p.String("o", "output", nil)
+			p.String("", "format", nil)
+		}, []string{"  -o, --output OUTPUT", "      --format FORMAT"}},
+		{"long only", func(p *Parser) {
+			p.String("", "output", nil)
+			p.String("", "format", nil)
+		}, []string{"  --output OUTPUT", "  --format FORMAT"}},
+		{"short only", func(p *Parser) {
+			p.String("o", "", nil)
+			p.String("", "format", nil)
+		}, []string{"  -o O", "      --format FORMAT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetHelpWidth(80)
+			tt.define(p)
+			help := p.HelpString()
+			for _, want := range tt.want {
+				if !strings.Contains(help, "\n"+want+" ") && !strings.Contains(help, "\n"+want+"\n") {
+					t.Errorf("help is missing the line %q:\n%s", want, help)
+				}
+			}
+		})
+	}
+}
+
+func TestHelpChoiceMetavars(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(p *Parser)
+		want   string
+	}{
+		{"inline choices", func(p *Parser) { p.String("", "format", nil).Choices([]string{"json", "yaml"}) }, "--format {json|yaml}"},
+		{"long choice list", func(p *Parser) {
+			p.String("", "level", nil).Choices([]string{"a", "b", "c", "d", "e", "f"})
+		}, "--level LEVEL"},
+		{"raised limit", func(p *Parser) {
+			p.SetMaxInlineChoices(6)
+			p.String("", "level", nil).Choices([]string{"a", "b", "c", "d", "e", "f"})
+		}, "--level {a|b|c|d|e|f}"},
+		{"explicit metavar", func(p *Parser) {
+			p.String("", "format", &Argument{Metavar: "FMT"}).Choices([]string{"json", "yaml"})
+		}, "--format FMT"},
+		{"positional in usage", func(p *Parser) { p.Positional("mode", nil).Choices([]string{"x", "y"}) }, "Usage: t [{x|y}]"},
+		{"required positional in usage", func(p *Parser) { p.Positional("mode", nil).Choices([]string{"x", "y"}).Required() }, "Usage: t {x|y}"},
+		{"default marked", func(p *Parser) {
+			p.String("", "format", nil).Choices([]string{"json", "yaml"}).Default("yaml")
+		}, "--format {json|yaml*}"},
+		{"non-string default marked", func(p *Parser) { p.Int("", "level", nil).Choices([]string{"1", "2"}).Default(2) }, "--level {1|2*}"},
+		{"positional default marked in usage", func(p *Parser) {
+			p.Positional("mode", nil).Choices([]string{"x", "y"}).Default("x")
+		}, "Usage: t [{x*|y}]"},
+		{"flag in usage", func(p *Parser) {
+			p.String("", "sort", nil).Choices([]string{"priority", "date", "title"})
+		}, "Usage: t [options] [--sort {priority|date|title}]"},
+		{"required flag in usage", func(p *Parser) {
+			p.String("s", "sort", nil).Choices([]string{"priority", "date"}).Required()
+		}, "Usage: t [options] --sort {priority|date}"},
+		{"range flag in usage", func(p *Parser) { p.Int("", "level", nil).ChoiceRange(1, 5) }, "Usage: t [options] [--level {1..5}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetHelpWidth(80)
+			tt.define(p)
+			if help := p.HelpString(); !strings.Contains(help, tt.want) {
+				t.Errorf("help is missing %q:\n%s", tt.want, help)
+			}
+		})
+	}
+}
+
+func TestCommandOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+	}{
+		{"alphabetical", []string{"add", "list", "remove"}},
+		{"reversed", []string{"remove", "list", "add"}},
+		{"mixed", []string{"list", "add", "remove", "clean"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetHelpWidth(80)
+			for _, name := range tt.commands {
+				p.NewCommand(name, "Run "+name)
+			}
+			help := p.HelpString()
+			if want := "{" + strings.Join(tt.commands, ",") + "}"; !strings.Contains(help, want) {
+				t.Errorf("usage is missing %q:\n%s", want, help)
+			}
+			last := -1
+			for _, name := range tt.commands {
+				i := strings.Index(help, "Run "+name)
+				if i < last {
+					t.Errorf("%s is listed out of order:\n%s", name, help)
+				}
+				last = i
+			}
+		})
+	}
+}
+
+func TestHelpWrapping(t *testing.T) {
+	description := "Write the generated report to this file, creating any missing parent directories first"
+	tests := []struct {
+		name  string
+		width int
+	}{
+		{"narrow", 60},
+		{"default", 80},
+		{"wide", 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetHelpWidth(tt.width)
+			p.String("o", "output", &Argument{Description: description})
+			help := p.HelpString()
+
+			column := descriptionColumn(help, "Write")
+			var words []string
+			for _, line := range strings.Split(help, "\n") {
+				if len([]rune(line)) > tt.width {
+					t.Errorf("line %q is wider than %d", line, tt.width)
+				}
+				if column > 0 && len(line) > column && strings.TrimSpace(line[:column]) == "" {
+					words = append(words, strings.Fields(line)...)
+				} else if i := strings.Index(line, "Write"); i >= 0 {
+					words = append(words, strings.Fields(line[i:])...)
+				}
+			}
+			if got := strings.Join(words, " "); got != description {
+				t.Errorf("description reassembled from aligned lines = %q, want %q:\n%s", got, description, help)
+			}
+		})
+	}
+}
+
+func TestArgumentGroups(t *testing.T) {
+	p := NewParser("t", "").SetHelpWidth(80)
+	p.Bool("v", "verbose", &Argument{Description: "Verbose"})
+	network := p.NewArgumentGroup("Network options")
+	output := p.NewArgumentGroup("Output options:")
+	network.String("", "host", &Argument{Description: "Host"})
+	output.String("o", "output", &Argument{Description: "Output file"})
+	network.Int("", "port", &Argument{Description: "Port"})
+	p.Bool("q", "quiet", &Argument{Description: "Quiet"})
+	help := p.HelpString()
+
+	// Each heading lists its own flags, in registration order
+	order := []string{"Optional arguments:", "Verbose", "Quiet", "Network options:", "Host", "Port", "Output options:", "Output file"}
+	last := -1
+	for _, want := range order {
+		i := strings.Index(help, want)
+		if i < 0 {
+			t.Fatalf("help is missing %q:\n%s", want, help)
+		}
+		if i < last {
+			t.Errorf("%q is out of order:\n%s", want, help)
+		}
+		last = i
+	}
+	if strings.Contains(help, "Output options::") {
+		t.Errorf("heading has a doubled colon:\n%s", help)
+	}
+
+	tests := []struct {
+		args []string
+		name string
+		want interface{}
+	}{
+		{[]string{"--host", "h"}, "host", "h"},
+		{[]string{"--port", "8080"}, "port", 8080},
+		{[]string{"-o", "out"}, "output", "out"},
+	}
+	for _, tt := range tests {
+		result, err := p.Parse(tt.args)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.args, err)
+		}
+		if result[tt.name] != tt.want {
+			t.Errorf("Parse(%q): %s = %v, want %v", tt.args, tt.name, result[tt.name], tt.want)
+		}
+	}
+}
+
+func TestSeeAlso(t *testing.T) {
+	tests := []struct {
+		name    string
+		enable  func(p, add *Parser)
+		help    func(p, add *Parser) string
+		want    []string
+		notWant []string
+	}{
+		{"disabled", func(p, add *Parser) {}, func(p, add *Parser) string { return add.HelpString() }, nil, []string{"See also:"}},
+		{"enabled on the root", func(p, add *Parser) { p.ShowSeeAlso(true) }, func(p, add *Parser) string { return add.HelpString() },
+			[]string{"See also:", "list", "List items", "remove"}, []string{"  add "}},
+		{"enabled on the command", func(p, add *Parser) { add.ShowSeeAlso(true) }, func(p, add *Parser) string { return add.HelpString() },
+			[]string{"See also:", "list", "remove"}, nil},
+		{"not on the root help", func(p, add *Parser) { p.ShowSeeAlso(true) }, func(p, add *Parser) string { return p.HelpString() },
+			nil, []string{"See also:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "").SetHelpWidth(80)
+			add := p.NewCommand("add", "Add an item").Parser
+			add.String("", "title", nil)
+			p.NewCommand("list", "List items")
+			p.NewCommand("remove", "Remove an item")
+			tt.enable(p, add)
+			help := tt.help(p, add)
+			for _, want := range tt.want {
+				if !strings.Contains(help, want) {
+					t.Errorf("help is missing %q:\n%s", want, help)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(help, notWant) {
+					t.Errorf("help contains %q:\n%s", notWant, help)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package argparse
+
+import "strings"
+
+// Lint checks the definition of the parser and all its subcommands without
+// parsing, returning every problem found: positional ordering, command name
+// conflicts, invalid specs, and groups that contradict themselves or other
+// settings. A clean definition gives an empty slice.
+func (p *Parser) Lint() []error {
+	errs := make([]error, 0)
+	for _, command := range p.commandPaths("") {
+		errs = append(errs, command.parser.lint()...)
+	}
+	return errs
+}
+
+// lint checks the parser's own definition
+func (p *Parser) lint() []error {
+	errs := make([]error, 0)
+	if p.defErr != nil {
+		errs = append(errs, p.defErr)
+	}
+	if err := p.checkPositionalOrder(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := p.checkCommandConflicts(); err != nil {
+		errs = append(errs, err)
+	}
+	if p.defaultCmd != "" {
+		if _, ok := p.subparsers[p.defaultCmd]; !ok {
+			errs = append(errs, p.errorf(KindInvalidDefinition, p.defaultCmd, p.msgs().UnknownDefaultCommand, p.defaultCmd))
+		}
+	}
+	for _, group := range p.groups {
+		errs = append(errs, group.lint()...)
+	}
+	for _, req := range p.requires {
+		for _, name := range req.names {
+			if p.findRequired(name) == nil {
+				errs = append(errs, p.errorf(KindInvalidDefinition, name, p.msgs().UnknownRequirement, name))
+			}
+		}
+	}
+	return errs
+}
+
+// lint reports group settings that can never be satisfied or that conflict
+// with how the members are defined
+func (g *Group) lint() []error {
+	p := g.parser
+	msgs := p.msgs()
+	errs := make([]error, 0)
+	labels := strings.Join(g.labels(), ", ")
+
+	if g.min > g.max || g.min > len(g.members) {
+		errs = append(errs, p.errorf(KindInvalidDefinition, "", msgs.GroupImpossible, labels, g.min, g.max, len(g.members)))
+	}
+	for i, arg := range g.members {
+		display := strings.Fields(g.labels()[i])[0]
+		switch {
+		case arg.IsPersistent:
+			errs = append(errs, p.errorf(KindInvalidDefinition, display, msgs.GroupPersistentMember, display, p.name))
+		case arg.parent != p:
+			errs = append(errs, p.errorf(KindInvalidDefinition, display, msgs.GroupForeignMember, display, p.name))
+		}
+		if arg.IsRequired {
+			errs = append(errs, p.errorf(KindInvalidDefinition, display, msgs.GroupRequiredMember, display))
+		}
+	}
+	return errs
+}
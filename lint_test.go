@@ -0,0 +1,60 @@
+package argparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(p *Parser)
+		want   []string
+	}{
+		{"clean", func(p *Parser) {
+			sub := p.NewCommand("sub", "").Parser
+			sub.NewExactlyOneGroup().Add(sub.Bool("", "json", nil), sub.Bool("", "text", nil))
+		}, nil},
+		{"persistent member in a subcommand group", func(p *Parser) {
+			verbose := p.Bool("", "verbose", nil).Persistent()
+			sub := p.NewCommand("sub", "").Parser
+			sub.NewExactlyOneGroup().Add(verbose, sub.Bool("", "quiet", nil))
+		}, []string{"group member --verbose of command sub is a persistent flag shared with other commands"}},
+		{"member of another command", func(p *Parser) {
+			debug := p.Bool("", "debug", nil)
+			sub := p.NewCommand("sub", "").Parser
+			sub.NewExactlyOneGroup().Add(debug, sub.Bool("", "quiet", nil))
+		}, []string{"group member --debug is not a flag of command sub"}},
+		{"required member in a nested command", func(p *Parser) {
+			sub := p.NewCommand("sub", "").Parser
+			leaf := sub.NewCommand("leaf", "").Parser
+			leaf.NewExactlyOneGroup().Add(leaf.String("", "id", nil).Required(), leaf.Bool("", "all", nil))
+		}, []string{"group member --id is required"}},
+		{"impossible range", func(p *Parser) {
+			p.NewCountRangeGroup(3, 4).Add(p.Bool("", "a", nil), p.Bool("", "b", nil))
+		}, []string{"group --a, --b requires 3 to 4 flags but has 2 members"}},
+		{"required positional after variadic", func(p *Parser) {
+			p.Positional("src", nil).Nargs("+")
+			p.Positional("dst", nil).Required()
+		}, []string{"required positional dst follows variadic positional src"}},
+		{"several problems", func(p *Parser) {
+			p.RequireOneOf("missing")
+			p.SetDefaultCommand("bogus")
+		}, []string{"default command bogus is not defined", "argument missing in a requirement is not defined"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			tt.define(p)
+			errs := p.Lint()
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Lint() = %v, want %d errors", errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("Lint()[%d] = %q, want it to contain %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
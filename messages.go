@@ -0,0 +1,233 @@
+package argparse
+
+// Messages holds the user-facing strings used in errors and help output.
+// Each field is a fmt format string; the verbs must match the defaults.
+type Messages struct {
+	// Help output
+	Usage                  string // %s program name
+	UsageOptions           string
+	PositionalHeader       string
+	OptionalHeader         string
+	CommandsHeader         string
+	SeeAlsoHeader          string
+	ErrorPrefix            string // %v error
+	HelpDescription        string
+	VersionDescription     string
+	VerboseDescription     string
+	QuietDescription       string
+	ListChoicesDescription string
+	DefaultSuffix          string // %s default value
+
+	// Parse errors
+	InvalidValue           string // %s argument, %v cause
+	RequiresValue          string // %s argument
+	UnknownArgument        string // %s argument
+	UnrecognizedPositional string // %s value
+	RequiredMissing        string // %s argument
+	RequiredPositional     string // %s argument
+	NotAllowedWithCommand  string // %s argument, %s command
+	DefaultCycle           string // %s argument
+	PositionalOrder        string // %s required positional, %s optional positional
+	VariadicOrder          string // %s required positional, %s variadic positional
+	InvalidSpec            string // %s spec
+	InvalidChoice          string // %s argument, %q value, %s choices
+	RequiresEquals         string // %s argument, %s argument
+	TooManyArguments       string // %d count, %d limit
+	ExactlyOne             string // %s arguments, %d count given
+	DeprecatedFlag         string // %s argument, %s replacement
+	CommandConflict        string // %s positional, %q command
+	UnknownIgnored         string // %s argument
+	OutOfRange             string // %s value, %s argument, %s low, %s high
+	UnknownDefaultCommand  string // %s command
+	AmbiguousOption        string // %s argument, %s candidates
+	InvalidNargs           string // %q nargs, %s argument
+	PositionalCount        string // %s argument, %d count wanted, %d count given
+	CountRange             string // %d min, %d max, %s arguments, %d count given
+	GroupImpossible        string // %s arguments, %d min, %d max, %d members
+	GroupForeignMember     string // %s argument, %s command
+	GroupPersistentMember  string // %s argument, %s command
+	GroupRequiredMember    string // %s argument
+	RequireTogether        string // %s arguments, %s missing arguments
+	RequireOneOf           string // %s arguments
+	UnknownRequirement     string // %s argument
+	InvalidChoiceRange     string // %d lo, %d hi, %s argument
+
+	// Value, config and definition errors
+	UnknownFeature     string // %q feature, %s choices
+	NoFilesMatch       string // %q pattern
+	UnknownType        string // %s type
+	UnknownUnit        string // %q unit, %s units
+	NegativeUnsigned   string // %s value
+	InvalidJSON        string // %v cause
+	InvalidTimeOfDay   string // %q value
+	TimeOfDayRange     string // %q value, %d field, %d max
+	InvalidDateTime    string // %s layouts
+	UnsupportedShell   string // %q shell
+	InvalidConfig      string // %s path, %v cause
+	InvalidConfigValue string // %s path, %s key, %v cause
+	UnknownConfigKeys  string // %s path, %s keys
+	BindTarget         string // %T target
+	BindCommandField   string // %s field
+	BindTagOption      string // %q option, %s field
+	BindFieldType      string // %s field, %s type
+	BindStore          string // %s argument, %T value, %s field, %s type
+}
+
+// DefaultMessages returns the built-in English messages
+func DefaultMessages() Messages {
+	return Messages{
+		Usage:                  "Usage: %s",
+		UsageOptions:           " [options]",
+		PositionalHeader:       "Positional arguments:",
+		OptionalHeader:         "Optional arguments:",
+		CommandsHeader:         "Commands:",
+		SeeAlsoHeader:          "See also:",
+		ErrorPrefix:            "Error: %v",
+		HelpDescription:        "Show this help message and exit",
+		VersionDescription:     "Show program's version and exit",
+		VerboseDescription:     "Increase output verbosity (repeatable)",
+		QuietDescription:       "Decrease output verbosity (repeatable)",
+		ListChoicesDescription: "List the valid choices of argument NAME and exit",
+		DefaultSuffix:          "(default: %s)",
+
+		InvalidValue:           "invalid value for %s: %v",
+		RequiresValue:          "argument %s requires a value",
+		UnknownArgument:        "unknown argument: %s",
+		UnrecognizedPositional: "unrecognized positional argument: %s",
+		RequiredMissing:        "required argument missing: %s",
+		RequiredPositional:     "required positional argument missing: %s",
+		NotAllowedWithCommand:  "argument %s is not allowed with command %s",
+		DefaultCycle:           "default cycle detected at argument: %s",
+		PositionalOrder:        "required positional %s follows optional positional %s; declare required positionals first",
+		VariadicOrder:          "required positional %s follows variadic positional %s, which takes every remaining token; declare the variadic positional last",
+		InvalidSpec:            "invalid argument spec %q: the short name must be a single character",
+		InvalidChoice:          "invalid choice %[2]q for %[1]s (choose from %[3]s)",
+		RequiresEquals:         "argument %s requires a value in the form %s=VALUE",
+		TooManyArguments:       "too many arguments: %d (limit %d)",
+		ExactlyOne:             "exactly one of %s is required (%d given)",
+		DeprecatedFlag:         "Warning: %s is deprecated; use %s instead",
+		CommandConflict:        "positional %s accepts %q, which is also a command name",
+		UnknownIgnored:         "Warning: ignoring unknown argument: %s",
+		OutOfRange:             "value %s for %s out of range [%s, %s]",
+		UnknownDefaultCommand:  "default command %s is not defined",
+		AmbiguousOption:        "ambiguous option %s (could match %s)",
+		InvalidNargs:           "invalid nargs %q for %s (use ?, *, + or a number)",
+		PositionalCount:        "positional %s requires %d values, got %d",
+		CountRange:             "between %d and %d of %s are required (%d given)",
+		GroupImpossible:        "group %s requires %d to %d flags but has %d members",
+		GroupForeignMember:     "group member %s is not a flag of command %s",
+		GroupPersistentMember:  "group member %s of command %s is a persistent flag shared with other commands",
+		GroupRequiredMember:    "group member %s is required; the group decides how many members are needed",
+		RequireTogether:        "%s must be given together (missing %s)",
+		RequireOneOf:           "at least one of %s is required",
+		UnknownRequirement:     "argument %s in a requirement is not defined",
+		InvalidChoiceRange:     "invalid choice range %d..%d for %s (lo is above hi)",
+
+		UnknownFeature:     "unknown feature %q (choose from %s)",
+		NoFilesMatch:       "no files match %q",
+		UnknownType:        "unknown argument type: %s",
+		UnknownUnit:        "unknown unit %q (use %s)",
+		NegativeUnsigned:   "negative value %s for unsigned argument",
+		InvalidJSON:        "invalid JSON: %v",
+		InvalidTimeOfDay:   "invalid time of day %q (use HH:MM or HH:MM:SS)",
+		TimeOfDayRange:     "time of day %q has %d out of range 0-%d",
+		InvalidDateTime:    "invalid datetime format (tried %s)",
+		UnsupportedShell:   "unsupported shell %q (use bash or zsh)",
+		InvalidConfig:      "%s: %v",
+		InvalidConfigValue: "%s: invalid value for %s: %v",
+		UnknownConfigKeys:  "%s: unknown keys: %s",
+		BindTarget:         "argparse: bind target must be a pointer to a struct, got %T",
+		BindCommandField:   "argparse: command field %s must be a struct or pointer to struct",
+		BindTagOption:      "argparse: invalid arg tag option %q on field %s",
+		BindFieldType:      "argparse: cannot bind field %s of type %s",
+		BindStore:          "argparse: cannot store %s (%T) in field %s (%s)",
+	}
+}
+
+// SetMessages replaces the messages used by the parser and its subcommands.
+// Empty fields fall back to the default English text.
+func (p *Parser) SetMessages(messages Messages) *Parser {
+	defaults := DefaultMessages()
+	fill := func(field *string, def string) {
+		if *field == "" {
+			*field = def
+		}
+	}
+	fill(&messages.Usage, defaults.Usage)
+	fill(&messages.UsageOptions, defaults.UsageOptions)
+	fill(&messages.PositionalHeader, defaults.PositionalHeader)
+	fill(&messages.OptionalHeader, defaults.OptionalHeader)
+	fill(&messages.CommandsHeader, defaults.CommandsHeader)
+	fill(&messages.SeeAlsoHeader, defaults.SeeAlsoHeader)
+	fill(&messages.ErrorPrefix, defaults.ErrorPrefix)
+	fill(&messages.HelpDescription, defaults.HelpDescription)
+	fill(&messages.VersionDescription, defaults.VersionDescription)
+	fill(&messages.VerboseDescription, defaults.VerboseDescription)
+	fill(&messages.QuietDescription, defaults.QuietDescription)
+	fill(&messages.ListChoicesDescription, defaults.ListChoicesDescription)
+	fill(&messages.DefaultSuffix, defaults.DefaultSuffix)
+	fill(&messages.InvalidValue, defaults.InvalidValue)
+	fill(&messages.RequiresValue, defaults.RequiresValue)
+	fill(&messages.UnknownArgument, defaults.UnknownArgument)
+	fill(&messages.UnrecognizedPositional, defaults.UnrecognizedPositional)
+	fill(&messages.RequiredMissing, defaults.RequiredMissing)
+	fill(&messages.RequiredPositional, defaults.RequiredPositional)
+	fill(&messages.NotAllowedWithCommand, defaults.NotAllowedWithCommand)
+	fill(&messages.DefaultCycle, defaults.DefaultCycle)
+	fill(&messages.PositionalOrder, defaults.PositionalOrder)
+	fill(&messages.VariadicOrder, defaults.VariadicOrder)
+	fill(&messages.InvalidSpec, defaults.InvalidSpec)
+	fill(&messages.InvalidChoice, defaults.InvalidChoice)
+	fill(&messages.RequiresEquals, defaults.RequiresEquals)
+	fill(&messages.TooManyArguments, defaults.TooManyArguments)
+	fill(&messages.ExactlyOne, defaults.ExactlyOne)
+	fill(&messages.DeprecatedFlag, defaults.DeprecatedFlag)
+	fill(&messages.CommandConflict, defaults.CommandConflict)
+	fill(&messages.UnknownIgnored, defaults.UnknownIgnored)
+	fill(&messages.OutOfRange, defaults.OutOfRange)
+	fill(&messages.UnknownDefaultCommand, defaults.UnknownDefaultCommand)
+	fill(&messages.AmbiguousOption, defaults.AmbiguousOption)
+	fill(&messages.InvalidNargs, defaults.InvalidNargs)
+	fill(&messages.PositionalCount, defaults.PositionalCount)
+	fill(&messages.CountRange, defaults.CountRange)
+	fill(&messages.GroupImpossible, defaults.GroupImpossible)
+	fill(&messages.GroupForeignMember, defaults.GroupForeignMember)
+	fill(&messages.GroupPersistentMember, defaults.GroupPersistentMember)
+	fill(&messages.GroupRequiredMember, defaults.GroupRequiredMember)
+	fill(&messages.RequireTogether, defaults.RequireTogether)
+	fill(&messages.RequireOneOf, defaults.RequireOneOf)
+	fill(&messages.UnknownRequirement, defaults.UnknownRequirement)
+	fill(&messages.InvalidChoiceRange, defaults.InvalidChoiceRange)
+	fill(&messages.UnknownFeature, defaults.UnknownFeature)
+	fill(&messages.NoFilesMatch, defaults.NoFilesMatch)
+	fill(&messages.UnknownType, defaults.UnknownType)
+	fill(&messages.UnknownUnit, defaults.UnknownUnit)
+	fill(&messages.NegativeUnsigned, defaults.NegativeUnsigned)
+	fill(&messages.InvalidJSON, defaults.InvalidJSON)
+	fill(&messages.InvalidTimeOfDay, defaults.InvalidTimeOfDay)
+	fill(&messages.TimeOfDayRange, defaults.TimeOfDayRange)
+	fill(&messages.InvalidDateTime, defaults.InvalidDateTime)
+	fill(&messages.UnsupportedShell, defaults.UnsupportedShell)
+	fill(&messages.InvalidConfig, defaults.InvalidConfig)
+	fill(&messages.InvalidConfigValue, defaults.InvalidConfigValue)
+	fill(&messages.UnknownConfigKeys, defaults.UnknownConfigKeys)
+	fill(&messages.BindTarget, defaults.BindTarget)
+	fill(&messages.BindCommandField, defaults.BindCommandField)
+	fill(&messages.BindTagOption, defaults.BindTagOption)
+	fill(&messages.BindFieldType, defaults.BindFieldType)
+	fill(&messages.BindStore, defaults.BindStore)
+
+	p.messages = &messages
+	return p
+}
+
+// msgs returns the messages in effect, inherited from the nearest parent that set them
+func (p *Parser) msgs() *Messages {
+	for parser := p; parser != nil; parser = parser.parent {
+		if parser.messages != nil {
+			return parser.messages
+		}
+	}
+	defaults := DefaultMessages()
+	return &defaults
+}
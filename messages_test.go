@@ -0,0 +1,108 @@
+package argparse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMessagesTranslateValueErrors(t *testing.T) {
+	msgs := DefaultMessages()
+	msgs.InvalidValue = "valor inválido para %s: %v"
+	msgs.UnknownUnit = "unidad desconocida %q (use %s)"
+	msgs.NegativeUnsigned = "valor negativo %s"
+	msgs.InvalidJSON = "JSON inválido: %v"
+	msgs.InvalidTimeOfDay = "hora inválida %q"
+	msgs.TimeOfDayRange = "hora %q: %d fuera de 0-%d"
+	msgs.InvalidDateTime = "fecha inválida (formatos %s)"
+	msgs.UnknownFeature = "función desconocida %q (elija %s)"
+	msgs.NoFilesMatch = "ningún archivo coincide con %q"
+
+	tests := []struct {
+		define func(p *Parser)
+		args   []string
+		want   string
+	}{
+		{func(p *Parser) { p.Float("", "size", nil).Unit("m", map[string]float64{"km": 1000}) }, []string{"--size", "5mi"}, `unidad desconocida "mi" (use m, km)`},
+		{func(p *Parser) { p.Uint("", "n", nil) }, []string{"--n", "-1"}, "valor negativo -1"},
+		{func(p *Parser) { p.JSON("", "data", nil) }, []string{"--data", "{"}, "JSON inválido: "},
+		{func(p *Parser) { p.TimeOfDay("", "at", nil) }, []string{"--at", "noon"}, `hora inválida "noon"`},
+		{func(p *Parser) { p.TimeOfDay("", "at", nil) }, []string{"--at", "25:00"}, `hora "25:00": 25 fuera de 0-23`},
+		{func(p *Parser) { p.DateTime("", "when", nil).Layout("2006-01-02") }, []string{"--when", "x"}, "fecha inválida (formatos 2006-01-02)"},
+		{func(p *Parser) { p.FeatureSet("", "with", nil).Choices([]string{"a", "b"}) }, []string{"--with", "c"}, `función desconocida "c" (elija a, b)`},
+		{func(p *Parser) { p.Glob("", "logs", nil).RequireMatch() }, []string{"--logs", "/nonexistent/*.log"}, `ningún archivo coincide con "/nonexistent/*.log"`},
+	}
+	for _, tt := range tests {
+		p := NewParser("t", "").SetMessages(msgs)
+		tt.define(p)
+		_, err := p.Parse(tt.args)
+		if err == nil || !strings.HasPrefix(err.Error(), "valor inválido para") || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want it to contain %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestMessagesTranslateOtherErrors(t *testing.T) {
+	msgs := DefaultMessages()
+	msgs.UnsupportedShell = "shell no soportado %q"
+	msgs.InvalidConfigValue = "%s: valor inválido para %s: %v"
+	msgs.UnknownConfigKeys = "%s: claves desconocidas: %s"
+	msgs.BindTarget = "destino inválido %T"
+
+	p := NewParser("t", "").SetMessages(msgs)
+	p.Int("", "level", nil)
+	if _, err := p.GenerateCompletion("fish"); err == nil || err.Error() != `shell no soportado "fish"` {
+		t.Errorf("GenerateCompletion(fish) error = %v", err)
+	}
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	unknown := filepath.Join(dir, "unknown.json")
+	if err := os.WriteFile(bad, []byte(`{"level": "x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(unknown, []byte(`{"other": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadConfig(bad); err == nil || !strings.Contains(err.Error(), "valor inválido para level") {
+		t.Errorf("LoadConfig(bad) error = %v", err)
+	}
+	if err := p.LoadConfig(unknown); err == nil || !strings.HasSuffix(err.Error(), "claves desconocidas: other") {
+		t.Errorf("LoadConfig(unknown) error = %v", err)
+	}
+
+	p.Bind(42)
+	if _, err := p.Parse([]string{}); err == nil || err.Error() != "destino inválido int" {
+		t.Errorf("Parse after Bind(42) error = %v", err)
+	}
+}
+
+func TestSetMessages(t *testing.T) {
+	msgs := Messages{
+		Usage:           "Uso: %s",
+		OptionalHeader:  "Opciones:",
+		RequiredMissing: "falta el argumento obligatorio: %s",
+	}
+	p := NewParser("t", "").SetMessages(msgs)
+	p.String("", "name", nil).Required()
+	sub := p.NewCommand("add", "").Parser
+	sub.String("", "title", nil).Required()
+
+	tests := []struct {
+		name string
+		got  func() string
+		want string
+	}{
+		{"usage", p.HelpString, "Uso: t"},
+		{"header", p.HelpString, "Opciones:"},
+		{"default kept", p.HelpString, DefaultMessages().CommandsHeader},
+		{"error", func() string { _, err := p.Parse([]string{}); return err.Error() }, "falta el argumento obligatorio: --name"},
+		{"inherited", func() string { _, err := p.Parse([]string{"--name", "x", "add"}); return err.Error() }, "falta el argumento obligatorio: --title"},
+	}
+	for _, tt := range tests {
+		if got := tt.got(); !strings.Contains(got, tt.want) {
+			t.Errorf("%s: got %q, want it to contain %q", tt.name, got, tt.want)
+		}
+	}
+}
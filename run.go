@@ -0,0 +1,138 @@
+package argparse
+
+import (
+	"context"
+)
+
+// Handler runs a command after its arguments have been parsed
+type Handler func(ctx context.Context, p *Parser) error
+
+// SetHandler sets the function RunContext calls when this parser's command is selected
+func (p *Parser) SetHandler(handler Handler) *Parser {
+	p.handler = handler
+	return p
+}
+
+// Hook runs before or after a command's handler
+type Hook func(p *Parser) error
+
+// SetPreRun sets a function run just before this parser's handler
+func (p *Parser) SetPreRun(hook Hook) *Parser {
+	p.preRun = hook
+	return p
+}
+
+// SetPostRun sets a function run just after this parser's handler succeeds
+func (p *Parser) SetPostRun(hook Hook) *Parser {
+	p.postRun = hook
+	return p
+}
+
+// SetPersistentPreRun sets a function run before the handler of this parser
+// or any command beneath it. Those of ancestors run first.
+func (p *Parser) SetPersistentPreRun(hook Hook) *Parser {
+	p.preRunAll = hook
+	return p
+}
+
+// SetPersistentPostRun sets a function run after the handler of this parser
+// or any command beneath it succeeds. Those of ancestors run last.
+func (p *Parser) SetPersistentPostRun(hook Hook) *Parser {
+	p.postRunAll = hook
+	return p
+}
+
+// CommandModule is a self-contained subcommand, such as one contributed by a plugin
+type CommandModule interface {
+	// Name returns the subcommand name
+	Name() string
+	// Describe defines the subcommand's description and arguments
+	Describe(p *Parser)
+	// Run runs the subcommand after its arguments have been parsed
+	Run(p *Parser) error
+}
+
+// AddCommandModule registers m as a subcommand whose handler is m.Run
+func (p *Parser) AddCommandModule(m CommandModule) *Parser {
+	sub := p.NewCommand(m.Name(), "").Parser
+	m.Describe(sub)
+	sub.SetHandler(func(ctx context.Context, p *Parser) error {
+		return m.Run(p)
+	})
+	return p
+}
+
+// Run parses os.Args and dispatches to the selected command's handler
+func (p *Parser) Run() error {
+	return p.RunContext(context.Background())
+}
+
+// RunContext parses os.Args and calls the handler of the most specific selected
+// command that has one, passing ctx through for cancellation and deadlines
+func (p *Parser) RunContext(ctx context.Context) error {
+	if _, err := p.Parse(nil); err != nil {
+		if !p.root().noExit && p.printRequest(err) {
+			p.exit(p.requestExitCode(err))
+		}
+		return err
+	}
+
+	return p.dispatch(ctx)
+}
+
+// dispatch calls the handler of the most specific selected command that has
+// one, or of each chained command in turn
+func (p *Parser) dispatch(ctx context.Context) error {
+	target, handler := p, p.handler
+	for parser := p; ; {
+		if len(parser.chain) > 0 {
+			for _, name := range parser.chain {
+				if err := parser.subparsers[name].dispatch(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if parser.subparser == "" {
+			break
+		}
+		parser = parser.subparsers[parser.subparser]
+		if parser.handler != nil {
+			target, handler = parser, parser.handler
+		}
+	}
+
+	if handler == nil {
+		return nil
+	}
+	return target.runHandler(ctx, handler)
+}
+
+// runHandler runs handler for p with the pre- and post-run hooks around it:
+// persistent pre-runs from the root down, the pre-run, the handler, the
+// post-run, then persistent post-runs back up. The first error stops the run.
+func (p *Parser) runHandler(ctx context.Context, handler Handler) error {
+	lineage := make([]*Parser, 0)
+	for parser := p; parser != nil; parser = parser.parent {
+		lineage = append([]*Parser{parser}, lineage...)
+	}
+
+	hooks := make([]Hook, 0)
+	for _, parser := range lineage {
+		hooks = append(hooks, parser.preRunAll)
+	}
+	hooks = append(hooks, p.preRun, func(*Parser) error { return handler(ctx, p) }, p.postRun)
+	for i := len(lineage) - 1; i >= 0; i-- {
+		hooks = append(hooks, lineage[i].postRunAll)
+	}
+
+	for _, hook := range hooks {
+		if hook == nil {
+			continue
+		}
+		if err := hook(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
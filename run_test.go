@@ -0,0 +1,203 @@
+package argparse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// runArgs runs RunContext with args in place of the program's arguments
+func runArgs(t *testing.T, p *Parser, ctx context.Context, args ...string) error {
+	t.Helper()
+	saved := os.Args
+	t.Cleanup(func() { os.Args = saved })
+	os.Args = append([]string{"t"}, args...)
+	p.SetExitOnHelp(false).SetOutput(io.Discard)
+	return p.RunContext(ctx)
+}
+
+type ctxKey struct{}
+
+func TestRunContext(t *testing.T) {
+	errNoValue := errors.New("no context value")
+	handler := func(name string, ran *string) Handler {
+		return func(ctx context.Context, p *Parser) error {
+			if ctx.Value(ctxKey{}) != "v" {
+				return errNoValue
+			}
+			*ran = name
+			return ctx.Err()
+		}
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		cancel  bool
+		want    string
+		wantErr error
+	}{
+		{"root", []string{}, false, "root", nil},
+		{"command", []string{"add"}, false, "add", nil},
+		{"nested command without handler", []string{"add", "file"}, false, "add", nil},
+		{"cancelled", []string{"add"}, true, "add", context.Canceled},
+		{"help", []string{"add", "--help"}, false, "", ErrHelpRequested},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := ""
+			p := NewParser("t", "").SetHandler(handler("root", &ran))
+			add := p.NewCommand("add", "").Parser.SetHandler(handler("add", &ran))
+			add.AddHelp()
+			add.NewCommand("file", "")
+
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "v"))
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			if err := runArgs(t, p, ctx, tt.args...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RunContext(%q) error = %v, want %v", tt.args, err, tt.wantErr)
+			}
+			if ran != tt.want {
+				t.Errorf("RunContext(%q) ran %q, want %q", tt.args, ran, tt.want)
+			}
+		})
+	}
+}
+
+// greetModule is a CommandModule recording the name it greeted
+type greetModule struct {
+	name    string
+	greeted *string
+}
+
+func (m greetModule) Name() string { return m.name }
+
+func (m greetModule) Describe(p *Parser) {
+	p.String("", "who", nil).Default("world")
+}
+
+func (m greetModule) Run(p *Parser) error {
+	*m.greeted = m.name + " " + p.GetString("who")
+	return nil
+}
+
+func TestCommandModules(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"first module", []string{"hello", "--who", "you"}, "hello you", false},
+		{"second module", []string{"bye"}, "bye world", false},
+		{"flag of a module", []string{"--who", "you"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			greeted := ""
+			p := NewParser("t", "")
+			p.AddCommandModule(greetModule{"hello", &greeted}).AddCommandModule(greetModule{"bye", &greeted})
+			err := runArgs(t, p, context.Background(), tt.args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunContext(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if greeted != tt.want {
+				t.Errorf("RunContext(%q) greeted %q, want %q", tt.args, greeted, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandChaining(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"two commands", []string{"build", "--fast", "test", "-n", "2"}, []string{"build fast=true verbose=false", "test n=2 verbose=false"}, false},
+		{"persistent flag before", []string{"-v", "build", "test"}, []string{"build fast=false verbose=true", "test n=1 verbose=true"}, false},
+		{"persistent flag in a segment", []string{"build", "test", "-v"}, []string{"build fast=false verbose=false", "test n=1 verbose=true"}, false},
+		{"flag of another command", []string{"build", "-n", "2", "test"}, nil, true},
+		{"command name as a value", []string{"test", "-n", "2", "build"}, []string{"test n=2 verbose=false", "build fast=false verbose=false"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			p := NewParser("t", "").AllowCommandChaining(true)
+			p.Bool("v", "verbose", nil).Persistent()
+			p.NewCommand("build", "").Parser.SetHandler(func(ctx context.Context, p *Parser) error {
+				ran = append(ran, fmt.Sprintf("build fast=%v verbose=%v", p.GetBool("fast"), p.GetBool("verbose")))
+				return nil
+			}).Bool("", "fast", nil)
+			p.NewCommand("test", "").Parser.SetHandler(func(ctx context.Context, p *Parser) error {
+				ran = append(ran, fmt.Sprintf("test n=%d verbose=%v", p.GetInt("num"), p.GetBool("verbose")))
+				return nil
+			}).Int("n", "num", &Argument{DefaultVal: 1})
+			err := runArgs(t, p, context.Background(), tt.args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunContext(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("RunContext(%q) ran %q, want %q", tt.args, ran, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name    string
+		args    []string
+		failAt  string
+		want    []string
+		wantErr error
+	}{
+		{"nested command", []string{"remote", "add"}, "", []string{
+			"root persistent pre", "remote persistent pre", "add pre", "add", "add post", "remote persistent post", "root persistent post",
+		}, nil},
+		{"middle command", []string{"remote"}, "", []string{
+			"root persistent pre", "remote persistent pre", "remote", "remote persistent post", "root persistent post",
+		}, nil},
+		{"pre-run error stops the run", []string{"remote", "add"}, "remote persistent pre", []string{
+			"root persistent pre", "remote persistent pre",
+		}, errFailed},
+		{"handler error skips post-runs", []string{"remote", "add"}, "add", []string{
+			"root persistent pre", "remote persistent pre", "add pre", "add",
+		}, errFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			record := func(name string) Hook {
+				return func(*Parser) error {
+					ran = append(ran, name)
+					if name == tt.failAt {
+						return errFailed
+					}
+					return nil
+				}
+			}
+			handler := func(name string) Handler {
+				return func(context.Context, *Parser) error { return record(name)(nil) }
+			}
+			p := NewParser("t", "").SetPersistentPreRun(record("root persistent pre")).SetPersistentPostRun(record("root persistent post"))
+			remote := p.NewCommand("remote", "").Parser.SetHandler(handler("remote")).
+				SetPersistentPreRun(record("remote persistent pre")).SetPersistentPostRun(record("remote persistent post"))
+			remote.NewCommand("add", "").Parser.SetHandler(handler("add")).SetPreRun(record("add pre")).SetPostRun(record("add post"))
+
+			if err := runArgs(t, p, context.Background(), tt.args...); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RunContext(%q) error = %v, want %v", tt.args, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("RunContext(%q) ran %q, want %q", tt.args, ran, tt.want)
+			}
+		})
+	}
+}
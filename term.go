@@ -0,0 +1,21 @@
+package argparse
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// terminalSize returns the column count of the terminal w writes to, if any
+func terminalSize(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, false
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || width <= 0 {
+		return 0, false
+	}
+	return width, true
+}
@@ -0,0 +1,422 @@
+package argparse
+
+import (
+	"errors"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterType(t *testing.T) {
+	RegisterType("test-ip", func(value string) (interface{}, error) {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, errors.New("not an IP address")
+		}
+		return ip, nil
+	})
+	tests := []struct {
+		name    string
+		typ     string
+		args    []string
+		want    interface{}
+		wantErr string
+	}{
+		{"registered", "test-ip", []string{"--addr", "10.0.0.1"}, net.ParseIP("10.0.0.1"), ""},
+		{"rejected", "test-ip", []string{"--addr", "x"}, nil, "not an IP address"},
+		{"unregistered", "test-missing", []string{"--addr", "x"}, nil, "unknown argument type: test-missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.String("", "addr", nil).Type(tt.typ)
+			result, err := p.Parse(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if !reflect.DeepEqual(result["addr"], tt.want) {
+				t.Errorf("addr = %v, want %v", result["addr"], tt.want)
+			}
+		})
+	}
+}
+
+func TestFeatureSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{"comma list", []string{"--with", "a,b"}, map[string]bool{"a": true, "b": true}, false},
+		{"repeated", []string{"--with", "a", "--with", "c"}, map[string]bool{"a": true, "c": true}, false},
+		{"spaces and empties", []string{"--with", " a, ,b "}, map[string]bool{"a": true, "b": true}, false},
+		{"unset", []string{}, map[string]bool{}, false},
+		{"unknown feature", []string{"--with", "a,z"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.FeatureSet("", "with", nil).Choices([]string{"a", "b", "c"})
+			_, err := p.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(p.GetStringSet("with"), tt.want) {
+				t.Errorf("GetStringSet(with) = %v, want %v", p.GetStringSet("with"), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    interface{}
+		wantErr bool
+	}{
+		{"object", `{"a":1,"b":[2,3]}`, map[string]interface{}{"a": 1.0, "b": []interface{}{2.0, 3.0}}, false},
+		{"array", `[1,"x",null]`, []interface{}{1.0, "x", nil}, false},
+		{"scalar", `"x"`, "x", false},
+		{"malformed", `{"a":`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.JSON("", "spec", nil)
+			_, err := p.Parse([]string{"--spec", tt.value})
+			if tt.wantErr {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || parseErr.Kind != KindInvalidValue || !strings.Contains(err.Error(), "invalid JSON") {
+					t.Errorf("Parse(%q) error = %v, want an invalid JSON error", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.value, err)
+			}
+			if got := p.GetJSON("spec"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetJSON = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr string
+	}{
+		{"5km", 5000, ""},
+		{"500m", 500, ""},
+		{"1.5km", 1500, ""},
+		{"20", 20, ""},
+		{"30cm", 0.3, ""},
+		{"5mi", 0, `unknown unit "mi" (use m, cm, km)`},
+		{"km", 0, "invalid syntax"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Float("", "distance", nil).Unit("m", map[string]float64{"km": 1000, "cm": 0.01})
+			result, err := p.Parse([]string{"--distance", tt.value})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.value, err)
+			}
+			if got := result["distance"].(float64); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("distance = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumericRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		define  func(p *Parser) *Argument
+		value   string
+		wantErr string
+	}{
+		{"int inside", func(p *Parser) *Argument { return p.Int("", "port", nil).Range(1, 65535) }, "80", ""},
+		{"int lower bound", func(p *Parser) *Argument { return p.Int("", "port", nil).Range(1, 65535) }, "1", ""},
+		{"int upper bound", func(p *Parser) *Argument { return p.Int("", "port", nil).Range(1, 65535) }, "65535", ""},
+		{"int above", func(p *Parser) *Argument { return p.Int("", "port", nil).Range(1, 65535) }, "70000", "value 70000 for --port out of range [1, 65535]"},
+		{"float inside", func(p *Parser) *Argument { return p.Float("", "ratio", nil).Range(0, 1) }, "0.5", ""},
+		{"float below", func(p *Parser) *Argument { return p.Float("", "ratio", nil).Range(0, 1) }, "-0.1", "value -0.1 for --ratio out of range [0, 1]"},
+		{"min only", func(p *Parser) *Argument { return p.Int("", "port", nil).Min(1024) }, "80", "out of range"},
+		{"max only", func(p *Parser) *Argument { return p.Int("", "port", nil).Max(1024) }, "80", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			arg := tt.define(p)
+			_, err := p.Parse([]string{"--" + arg.Name, tt.value})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse(%q) error = %v", tt.value, err)
+				}
+				return
+			}
+			var cause *InvalidValueError
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.As(err, &cause) {
+				t.Errorf("Parse(%q) error = %v, want an InvalidValueError containing %q", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"seconds", []string{"--timeout", "30s"}, 30 * time.Second, false},
+		{"compound", []string{"--timeout", "1m30s"}, 90 * time.Second, false},
+		{"default", []string{}, 5 * time.Second, false},
+		{"invalid", []string{"--timeout", "soon"}, 0, true},
+		{"missing unit", []string{"--timeout", "30"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Duration("", "timeout", &Argument{DefaultVal: 5 * time.Second})
+			_, err := p.Parse(tt.args)
+			if tt.wantErr {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || parseErr.Kind != KindInvalidValue {
+					t.Errorf("Parse(%q) error = %v, want an invalid value error", tt.args, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.GetDuration("timeout"); got != tt.want {
+				t.Errorf("GetDuration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name         string
+		pattern      string
+		requireMatch bool
+		want         []string
+		wantErr      bool
+	}{
+		{"matching", "*.log", false, []string{"a.log", "b.log"}, false},
+		{"no match", "*.gz", false, []string{}, false},
+		{"no match required", "*.gz", true, nil, true},
+		{"match required", "*.txt", true, []string{"c.txt"}, false},
+		{"malformed pattern", "[", false, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			logs := p.Glob("", "logs", nil)
+			if tt.requireMatch {
+				logs.RequireMatch()
+			}
+			result, err := p.Parse([]string{"--logs", filepath.Join(dir, tt.pattern)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, want error %v", tt.pattern, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := make([]string, 0)
+			for _, path := range result["logs"].([]string) {
+				got = append(got, filepath.Base(path))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("logs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWideIntegers(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantSize  int64
+		wantID    uint64
+		wantErr   string
+		wantErrOn string
+	}{
+		{"defaults", []string{}, -1, 7, "", ""},
+		{"large values", []string{"--size", "9000000000", "--id", "18446744073709551615"}, 9000000000, 18446744073709551615, "", ""},
+		{"negative int64", []string{"--size", "-9000000000"}, -9000000000, 7, "", ""},
+		{"negative uint", []string{"--id", "-1"}, 0, 0, "negative", "--id"},
+		{"uint overflow", []string{"--id", "18446744073709551616"}, 0, 0, "out of range", "--id"},
+		{"int64 not a number", []string{"--size", "big"}, 0, 0, "invalid syntax", "--size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Int64("", "size", &Argument{DefaultVal: int64(-1)})
+			p.Uint("", "id", &Argument{DefaultVal: uint64(7)})
+			_, err := p.Parse(tt.args)
+			if tt.wantErr != "" {
+				var cause *InvalidValueError
+				if !errors.As(err, &cause) || cause.Name != tt.wantErrOn || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse(%q) error = %v, want an invalid value for %s containing %q", tt.args, err, tt.wantErrOn, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got := p.GetInt64("size"); got != tt.wantSize {
+				t.Errorf("GetInt64 = %d, want %d", got, tt.wantSize)
+			}
+			if got := p.GetUint("id"); got != tt.wantID {
+				t.Errorf("GetUint = %d, want %d", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestFloat32(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float32
+		wantErr bool
+	}{
+		{"1.5", 1.5, false},
+		{"0.1", 0.1, false},
+		{"-1.25", -1.25, false},
+		{"3.4e38", 3.4e38, false},
+		{"3.5e38", 0, true},
+		{"x", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.Float32("", "ratio", nil)
+			p.Float("", "wide", nil)
+			_, err := p.Parse([]string{"--ratio", tt.value, "--wide", "0.1"})
+			if tt.wantErr {
+				var cause *InvalidValueError
+				if !errors.As(err, &cause) || cause.Name != "--ratio" {
+					t.Errorf("Parse(%q) error = %v, want an invalid value for --ratio", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.value, err)
+			}
+			if got := p.GetFloat32("ratio"); got != tt.want {
+				t.Errorf("GetFloat32 = %v, want %v", got, tt.want)
+			}
+			if got := p.GetFloat("wide"); got != 0.1 {
+				t.Errorf("GetFloat = %v, want float64 0.1", got)
+			}
+		})
+	}
+}
+
+func TestTimeOfDay(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr string
+	}{
+		{"14:30", 14*time.Hour + 30*time.Minute, ""},
+		{"00:00", 0, ""},
+		{"23:59:59", 23*time.Hour + 59*time.Minute + 59*time.Second, ""},
+		{"7:05", 7*time.Hour + 5*time.Minute, ""},
+		{"24:00", 0, `time of day "24:00" has 24 out of range 0-23`},
+		{"12:60", 0, `time of day "12:60" has 60 out of range 0-59`},
+		{"2pm", 0, `"2pm"`},
+		{"12:30:00:00", 0, `"12:30:00:00"`},
+		{"12:-1", 0, `"12:-1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			p := NewParser("t", "")
+			p.TimeOfDay("", "at", nil)
+			_, err := p.Parse([]string{"--at", tt.value})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.value, err)
+			}
+			if got := p.GetTimeOfDay("at"); got != tt.want {
+				t.Errorf("GetTimeOfDay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateTimeLayouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		layouts []string
+		value   string
+		want    time.Time
+		wantErr string
+	}{
+		{"default layout", nil, "2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), ""},
+		{"default layouts tried", nil, "05-Mar-2024", time.Time{},
+			"invalid datetime format (tried 2006-01-02T15:04:05Z07:00, 2006-01-02, 2006-01-02 15:04:05, 01/02/2006, 01/02/2006 15:04:05)"},
+		{"custom layout", []string{"02-Jan-2006"}, "05-Mar-2024", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), ""},
+		{"custom layout replaces defaults", []string{"02-Jan-2006"}, "2024-03-05", time.Time{}, "invalid datetime format (tried 02-Jan-2006)"},
+		{"unix seconds", []string{LayoutUnix}, "1700000000", time.Unix(1700000000, 0).UTC(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser("t", "")
+			when := p.DateTime("", "when", nil)
+			if tt.layouts != nil {
+				when.Layout(tt.layouts...)
+			}
+			_, err := p.Parse([]string{"--when", tt.value})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.value, err)
+			}
+			if got := p.GetDateTime("when"); !got.Equal(tt.want) {
+				t.Errorf("GetDateTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}